@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/xwb1989/sqlparser"
+)
+
+const countAlias = "count"
+
+// aggregation is a COUNT(*), SUM(field) or AVG(field) of an aggregation
+// statement.
+type aggregation struct {
+	Function string
+	Field    string
+	Alias    string
+}
+
+// aggregationStatement is a SELECT statement whose select list only holds
+// aggregations, e.g. SELECT SUM(total) AS revenue, COUNT(*) FROM orders.
+type aggregationStatement struct {
+	*selectStatement
+	Aggregations []aggregation
+}
+
+// parseAggregationStatement parses a SELECT statement of COUNT(*), SUM(field)
+// and AVG(field) aggregations. It reports false for any other statement.
+// Aggregations without an alias are named count, sum_<field> and
+// avg_<field>.
+func parseAggregationStatement(query string) (*aggregationStatement, bool) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil, false
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || len(sel.SelectExprs) == 0 || sel.GroupBy != nil {
+		return nil, false
+	}
+
+	var aggregations []aggregation
+	for _, expr := range sel.SelectExprs {
+		aliased, ok := expr.(*sqlparser.AliasedExpr)
+		if !ok {
+			return nil, false
+		}
+		fn, ok := aliased.Expr.(*sqlparser.FuncExpr)
+		if !ok || fn.Distinct || len(fn.Exprs) != 1 {
+			return nil, false
+		}
+		a := aggregation{Function: fn.Name.Lowered(), Alias: aliased.As.String()}
+		switch a.Function {
+		case "count":
+			if _, ok := fn.Exprs[0].(*sqlparser.StarExpr); !ok {
+				return nil, false
+			}
+			if a.Alias == "" {
+				a.Alias = countAlias
+			}
+		case "sum", "avg":
+			arg, ok := fn.Exprs[0].(*sqlparser.AliasedExpr)
+			if !ok {
+				return nil, false
+			}
+			col, ok := arg.Expr.(*sqlparser.ColName)
+			if !ok {
+				return nil, false
+			}
+			a.Field = columnPath(col)
+			if a.Alias == "" {
+				a.Alias = a.Function + "_" + strings.ReplaceAll(a.Field, ".", "_")
+			}
+		default:
+			return nil, false
+		}
+		aggregations = append(aggregations, a)
+	}
+
+	sel.SelectExprs = sqlparser.SelectExprs{&sqlparser.StarExpr{}}
+	parsed, err := parseSelectStatement(sqlparser.String(sel))
+	if err != nil {
+		return nil, false
+	}
+	return &aggregationStatement{selectStatement: parsed, Aggregations: aggregations}, true
+}
+
+// run runs the aggregations, which read index entries rather than
+// documents. COUNT values are returned as int64 and SUM and AVG values as
+// float64, or nil when no document has a numeric value for the field.
+func (s *aggregationStatement) run(ctx context.Context, client *firestore.Client) (*util.QueryResult, error) {
+	query, err := s.firestoreQuery(client)
+	if err != nil {
+		return nil, err
+	}
+	aq := query.NewAggregationQuery()
+	for _, a := range s.Aggregations {
+		switch a.Function {
+		case "count":
+			aq = aq.WithCount(a.Alias)
+		case "sum":
+			aq = aq.WithSum(a.Field, a.Alias)
+		case "avg":
+			aq = aq.WithAvg(a.Field, a.Alias)
+		}
+	}
+	values, err := aq.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &util.QueryResult{Records: [][]interface{}{make([]interface{}, len(s.Aggregations))}}
+	for i, a := range s.Aggregations {
+		result.Columns = append(result.Columns, a.Alias)
+		value, ok := values[a.Alias].(*firestorepb.Value)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %s aggregation result %v", a.Alias, values[a.Alias])
+		}
+		switch v := value.GetValueType().(type) {
+		case *firestorepb.Value_IntegerValue:
+			if a.Function == "count" {
+				result.Records[0][i] = v.IntegerValue
+			} else {
+				result.Records[0][i] = float64(v.IntegerValue)
+			}
+		case *firestorepb.Value_DoubleValue:
+			result.Records[0][i] = v.DoubleValue
+		case *firestorepb.Value_NullValue:
+		default:
+			return nil, fmt.Errorf("unexpected %s aggregation result %v", a.Alias, value)
+		}
+	}
+	return result, nil
+}
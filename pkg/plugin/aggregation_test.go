@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAggregationStatement(t *testing.T) {
+	stmt, ok := parseAggregationStatement("SELECT COUNT(*) FROM tickets WHERE status = 'open'")
+	require.True(t, ok)
+	require.Equal(t, "tickets", stmt.Collection)
+	require.Equal(t, []statementFilter{{Field: "status", Op: "==", Value: "open"}}, stmt.Filters)
+	require.Equal(t, []aggregation{{Function: "count", Alias: "count"}}, stmt.Aggregations)
+
+	stmt, ok = parseAggregationStatement("select sum(total) as revenue, avg(item.price), count(*) as cnt from orders")
+	require.True(t, ok)
+	require.Equal(t, "orders", stmt.Collection)
+	require.Empty(t, stmt.Filters)
+	require.Equal(t, []aggregation{
+		{Function: "sum", Field: "total", Alias: "revenue"},
+		{Function: "avg", Field: "item.price", Alias: "avg_item_price"},
+		{Function: "count", Alias: "cnt"},
+	}, stmt.Aggregations)
+
+	for _, query := range []string{
+		"select * from orders",
+		"select count(id) from orders",
+		"select max(total) from orders",
+		"select sum(total), status from orders",
+		"select count(*) from orders group by status",
+		"select count(*) from orders where total + 1 > 2",
+	} {
+		_, ok := parseAggregationStatement(query)
+		require.False(t, ok, query)
+	}
+}
+
+func seedAggregationOrders(t testing.TB, ctx context.Context) {
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for i := 0; i < 5; i++ {
+		status := "open"
+		if i%2 == 0 {
+			status = "closed"
+		}
+		_, err := client.Collection("aggregation_orders").Doc(fmt.Sprintf("o%d", i)).Set(ctx, map[string]interface{}{
+			"status": status,
+			"total":  i * 10,
+			"note":   "not a number",
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestQueryDataAggregations(t *testing.T) {
+	seedAggregationOrders(t, context.Background())
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := runQuery(t, ds, settings, `{"query": "select count(*) from aggregation_orders where status = 'open'"}`)
+	require.NoError(t, resp.Error)
+	count, _ := resp.Frames[0].FieldByName(countAlias)
+	require.Equal(t, int64(2), *count.At(0).(*int64))
+
+	resp = runQuery(t, ds, settings, `{"query": "select sum(total) as revenue, avg(total), count(*) as cnt, avg(note) from aggregation_orders"}`)
+	require.NoError(t, resp.Error)
+	frame := resp.Frames[0]
+	revenue, _ := frame.FieldByName("revenue")
+	require.Equal(t, 100.0, *revenue.At(0).(*float64))
+	avg, _ := frame.FieldByName("avg_total")
+	require.Equal(t, 20.0, *avg.At(0).(*float64))
+	cnt, _ := frame.FieldByName("cnt")
+	require.Equal(t, int64(5), *cnt.At(0).(*int64))
+	// Non-numeric values are ignored by Firestore
+	note, _ := frame.FieldByName("avg_note")
+	require.Nil(t, note.At(0))
+}
+
+// BenchmarkAggregationQuery compares a SUM aggregation with fetching the
+// documents to sum them client-side.
+func BenchmarkAggregationQuery(b *testing.B) {
+	seedAggregationOrders(b, context.Background())
+	ds := &Datasource{}
+	defer ds.Dispose()
+
+	for name, query := range map[string]string{
+		"aggregation": "select sum(total) from aggregation_orders",
+		"documents":   "select total from aggregation_orders",
+	} {
+		req := &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"ProjectId": "test"}`)},
+			},
+			Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(fmt.Sprintf(`{"query": %q}`, query))}},
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ds.QueryData(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// annotationQueryType is the query type of annotation queries.
+const annotationQueryType = "annotation"
+
+// AnnotationQuery is the model of annotation queries. Each document of
+// Collection whose TimeField, a timestamp or an RFC 3339 string in UTC ("Z"
+// suffix), falls in the dashboard time range is an annotation. Its text is the TextField value,
+// empty when missing, and its tags the values of the TagsField array; a
+// TagsField holding a single value is a single tag. For example:
+//
+//	deployments/{id}: {"deployedAt": Timestamp, "message": "v1.2.0", "tags": ["prod", "api"]}
+//
+// TimeField must be indexed for range queries, which Firestore does by default.
+// Timestamps and strings are fetched by two range queries, as Firestore only
+// compares values of the same type.
+type AnnotationQuery struct {
+	Collection string
+	TimeField  string
+	TextField  string
+	TagsField  string
+}
+
+// annotationQuery returns the annotations of aq over the time range.
+func (d *Datasource) annotationQuery(ctx context.Context, pCtx backend.PluginContext, aq AnnotationQuery, tr backend.TimeRange) (*data.Frame, error) {
+	if aq.Collection == "" || aq.TimeField == "" {
+		return nil, errors.New("Collection and TimeField are required for annotation queries")
+	}
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ref, err := collectionRef(client, aq.Collection)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := ref.
+		Where(aq.TimeField, ">=", tr.From).
+		Where(aq.TimeField, "<=", tr.To).
+		OrderBy(aq.TimeField, firestore.Asc).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	// RFC 3339 strings in UTC sort like the times they hold. The bounds are
+	// widened to whole seconds, as fractional seconds sort after the "Z" of
+	// whole ones, and the documents outside the range dropped afterwards.
+	stringSnapshots, err := ref.
+		Where(aq.TimeField, ">=", tr.From.UTC().Add(-time.Second).Format(time.RFC3339)).
+		Where(aq.TimeField, "<=", tr.To.UTC().Add(time.Second).Format(time.RFC3339)).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]map[string]interface{}, 0, len(snapshots)+len(stringSnapshots))
+	for _, snapshot := range append(snapshots, stringSnapshots...) {
+		docs = append(docs, snapshot.Data())
+	}
+	return newAnnotationFrame(annotationsInRange(docs, aq.TimeField, tr), aq), nil
+}
+
+// annotationsInRange returns the docs whose timeField falls in the time
+// range, sorted by time.
+func annotationsInRange(docs []map[string]interface{}, timeField string, tr backend.TimeRange) []map[string]interface{} {
+	var inRange []map[string]interface{}
+	for _, doc := range docs {
+		if t, ok := timeValue(doc[timeField]); ok && !t.Before(tr.From) && !t.After(tr.To) {
+			inRange = append(inRange, doc)
+		}
+	}
+	sort.SliceStable(inRange, func(i, j int) bool {
+		ti, _ := timeValue(inRange[i][timeField])
+		tj, _ := timeValue(inRange[j][timeField])
+		return ti.Before(tj)
+	})
+	return inRange
+}
+
+// newAnnotationFrame builds the time, text and tags annotation fields from
+// docs. Documents without a valid time are skipped.
+func newAnnotationFrame(docs []map[string]interface{}, aq AnnotationQuery) *data.Frame {
+	var (
+		times []*time.Time
+		texts []*string
+		tags  []*string
+	)
+	for _, doc := range docs {
+		t, ok := timeValue(doc[aq.TimeField])
+		if !ok {
+			continue
+		}
+		var text string
+		if v, ok := doc[aq.TextField]; ok && v != nil {
+			text, _ = formatValue(v)
+		}
+		times = append(times, &t)
+		texts = append(texts, &text)
+		tags = append(tags, annotationTags(doc[aq.TagsField]))
+	}
+
+	frame := data.NewFrame("annotations",
+		data.NewField("time", nil, times),
+		data.NewField("text", nil, texts),
+		data.NewField("tags", nil, tags),
+	)
+	frame.Meta = &data.FrameMeta{Type: data.FrameTypeTable}
+	return frame
+}
+
+// annotationTags joins the values of an array of tags with commas.
+func annotationTags(v interface{}) *string {
+	var values []interface{}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values = v
+	default:
+		values = []interface{}{v}
+	}
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		if tag, err := formatValue(value); err == nil && value != nil {
+			tags = append(tags, tag)
+		}
+	}
+	joined := strings.Join(tags, ",")
+	return &joined
+}
@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAnnotationFrame(t *testing.T) {
+	deployed := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	docs := []map[string]interface{}{
+		{"deployedAt": deployed, "message": "v1.2.0", "tags": []interface{}{"prod", "api"}},
+		{"deployedAt": deployed.Add(time.Hour), "tags": "hotfix"},
+		{"deployedAt": deployed.Add(2 * time.Hour).Format(time.RFC3339), "message": "v1.2.1"},
+		{"message": "no time"},
+	}
+
+	frame := newAnnotationFrame(docs, AnnotationQuery{TimeField: "deployedAt", TextField: "message", TagsField: "tags"})
+	require.Equal(t, 3, frame.Rows())
+	require.Equal(t, data.FrameTypeTable, frame.Meta.Type)
+
+	times, _ := frame.FieldByName("time")
+	require.Equal(t, deployed, *times.At(0).(*time.Time))
+	require.True(t, deployed.Add(2*time.Hour).Equal(*times.At(2).(*time.Time)))
+
+	texts, _ := frame.FieldByName("text")
+	require.Equal(t, "v1.2.0", *texts.At(0).(*string))
+	require.Equal(t, "", *texts.At(1).(*string))
+
+	tags, _ := frame.FieldByName("tags")
+	require.Equal(t, "prod,api", *tags.At(0).(*string))
+	require.Equal(t, "hotfix", *tags.At(1).(*string))
+	require.Nil(t, tags.At(2))
+}
+
+func TestAnnotationsInRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	docs := []map[string]interface{}{
+		{"at": from.Add(2 * time.Hour), "message": "timestamp"},
+		{"at": "2024-01-01T13:00:00.5Z", "message": "string"},
+		{"at": "2024-01-01T11:59:59.5Z", "message": "before"},
+		{"at": "2024-01-01T15:00:00Z", "message": "after"},
+	}
+	inRange := annotationsInRange(docs, "at", backend.TimeRange{From: from, To: from.Add(2 * time.Hour)})
+	require.Len(t, inRange, 2)
+	require.Equal(t, "string", inRange[0]["message"])
+	require.Equal(t, "timestamp", inRange[1]["message"])
+}
+
+func TestQueryDataAnnotationStringTimes(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	from := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for id, at := range map[string]interface{}{
+		"timestamp": from.Add(time.Hour),
+		"string":    from.Add(30 * time.Minute).Format(time.RFC3339Nano),
+		"outside":   from.Add(-time.Hour).Format(time.RFC3339),
+	} {
+		_, err := client.Collection("annotation_deploys").Doc(id).Set(ctx, map[string]interface{}{"at": at, "message": id})
+		require.NoError(t, err)
+	}
+
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+	ds := Datasource{}
+	defer ds.Dispose()
+	resp, err := ds.QueryData(ctx, &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings}},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			QueryType: annotationQueryType,
+			TimeRange: backend.TimeRange{From: from, To: from.Add(2 * time.Hour)},
+			JSON:      []byte(`{"collection": "annotation_deploys", "timeField": "at", "textField": "message"}`),
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+	texts, _ := resp.Responses["A"].Frames[0].FieldByName("text")
+	require.Equal(t, 2, texts.Len())
+	require.Equal(t, "string", *texts.At(0).(*string))
+	require.Equal(t, "timestamp", *texts.At(1).(*string))
+}
@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/xwb1989/sqlparser"
+)
+
+var (
+	arrayContainsAnyPattern = regexp.MustCompile(`(?i)([\w.]+)\s+ARRAY_CONTAINS_ANY\s*\(([^)]*)\)`)
+	arrayContainsPattern    = regexp.MustCompile(`(?i)([\w.]+)\s+ARRAY_CONTAINS\s+('(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|[-\w.]+)`)
+)
+
+// arrayOperators maps the functions standing for the array membership
+// operators to Firestore operators.
+var arrayOperators = map[string]string{
+	"array_contains":     "array-contains",
+	"array_contains_any": "array-contains-any",
+}
+
+// rewriteArrayOperators rewrites the `field ARRAY_CONTAINS value` and
+// `field ARRAY_CONTAINS_ANY (value, ...)` conditions of query, which are not
+// SQL, into array_contains(field, value) and array_contains_any(field,
+// value, ...) calls. FireQL does not support them, so only statements run
+// natively can use them.
+func rewriteArrayOperators(query string) string {
+	query = arrayContainsAnyPattern.ReplaceAllString(query, "array_contains_any($1, $2)")
+	return arrayContainsPattern.ReplaceAllString(query, "array_contains($1, $2)")
+}
+
+// arrayFilter returns the filter of an array_contains or array_contains_any
+// call.
+func arrayFilter(expr *sqlparser.FuncExpr) (statementFilter, error) {
+	op, ok := arrayOperators[expr.Name.Lowered()]
+	if !ok || len(expr.Exprs) < 2 {
+		return statementFilter{}, fmt.Errorf("unsupported WHERE clause: %s", sqlparser.String(expr))
+	}
+	var args []sqlparser.Expr
+	for _, e := range expr.Exprs {
+		aliased, ok := e.(*sqlparser.AliasedExpr)
+		if !ok {
+			return statementFilter{}, fmt.Errorf("unsupported WHERE clause: %s", sqlparser.String(expr))
+		}
+		args = append(args, aliased.Expr)
+	}
+	col, ok := args[0].(*sqlparser.ColName)
+	if !ok {
+		return statementFilter{}, fmt.Errorf("unsupported WHERE clause: %s", sqlparser.String(expr))
+	}
+
+	values := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		v, err := statementValue(arg)
+		if err != nil {
+			return statementFilter{}, err
+		}
+		values[i] = v
+	}
+	if op == "array-contains" {
+		if len(values) != 1 {
+			return statementFilter{}, fmt.Errorf("ARRAY_CONTAINS takes a single value: %s", sqlparser.String(expr))
+		}
+		return statementFilter{Field: columnPath(col), Op: op, Value: values[0]}, nil
+	}
+	return statementFilter{Field: columnPath(col), Op: op, Value: values}, nil
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteArrayOperators(t *testing.T) {
+	tests := []struct {
+		query, expected string
+	}{
+		{"select * from posts where tags array_contains 'golang'", "select * from posts where array_contains(tags, 'golang')"},
+		{"select * from posts where tags ARRAY_CONTAINS_ANY ('golang', 'rust') and year > 2020",
+			"select * from posts where array_contains_any(tags, 'golang', 'rust') and year > 2020"},
+		{"select * from posts where scores array_contains 3 order by year", "select * from posts where array_contains(scores, 3) order by year"},
+		{"select * from posts where title = 'x'", "select * from posts where title = 'x'"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, rewriteArrayOperators(test.query))
+	}
+}
+
+func TestParseArrayFilters(t *testing.T) {
+	stmt, err := parseSelectStatement(rewriteArrayOperators(
+		"select * from posts where tags array_contains 'golang' and labels array_contains_any ('a', 'b') order by year desc limit 5"))
+	require.NoError(t, err)
+	require.Equal(t, []statementFilter{
+		{Field: "tags", Op: "array-contains", Value: "golang"},
+		{Field: "labels", Op: "array-contains-any", Value: []interface{}{"a", "b"}},
+	}, stmt.Filters)
+	require.Len(t, stmt.OrderBy, 1)
+	require.Equal(t, 5, stmt.Limit)
+
+	_, err = parseSelectStatement("select * from posts where array_contains(tags, 'a', 'b')")
+	require.Error(t, err)
+	_, err = parseSelectStatement("select * from posts where upper(tags, 'a')")
+	require.Error(t, err)
+}
+
+func TestQueryDataArrayContains(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	posts := map[string][]interface{}{
+		"p1": {"golang", "grpc"},
+		"p2": {"rust"},
+		"p3": {"python"},
+	}
+	for id, tags := range posts {
+		_, err := client.Collection("array_posts").Doc(id).Set(ctx, map[string]interface{}{"title": id, "tags": tags})
+		require.NoError(t, err)
+	}
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := runQuery(t, ds, settings, `{"query": "select title from array_posts where tags array_contains 'golang'"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 1, resp.Frames[0].Rows())
+
+	resp = runQuery(t, ds, settings, `{"query": "select title from array_posts where tags array_contains_any ('golang', 'rust') order by title"}`)
+	require.NoError(t, resp.Error)
+	titles, _ := resp.Frames[0].FieldByName("title")
+	require.Equal(t, 2, titles.Len())
+	require.Equal(t, "p1", *titles.At(0).(*string))
+	require.Equal(t, "p2", *titles.At(1).(*string))
+}
@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// Array handling modes for FirestoreQuery.ArrayHandling.
+const (
+	arrayHandlingJSON   = "json"
+	arrayHandlingLength = "length"
+	arrayHandlingFirst  = "first"
+)
+
+// handleArrays replaces the array values of result according to mode:
+// "length" replaces them with their number of elements and "first" with
+// their first element, or nil when empty. With "json", the default, arrays
+// are left untouched and encoded as JSON in the frame.
+func handleArrays(result *util.QueryResult, mode string) error {
+	switch mode {
+	case "", arrayHandlingJSON:
+		return nil
+	case arrayHandlingLength, arrayHandlingFirst:
+	default:
+		return fmt.Errorf("unknown array handling %q", mode)
+	}
+
+	for _, record := range result.Records {
+		for i, value := range record {
+			array, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+			switch {
+			case mode == arrayHandlingLength:
+				record[i] = int64(len(array))
+			case len(array) > 0:
+				record[i] = array[0]
+			default:
+				record[i] = nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func arrayResult() *util.QueryResult {
+	return &util.QueryResult{
+		Columns: []string{"tags"},
+		Records: [][]interface{}{
+			{[]interface{}{}},
+			{[]interface{}{"a", "b"}},
+			{[]interface{}{int64(1), int64(2)}},
+			{[]interface{}{[]interface{}{"x"}, "y"}},
+			{[]interface{}{"a", int64(1), true, nil}},
+		},
+	}
+}
+
+func TestArrayHandlingJSON(t *testing.T) {
+	field, _ := newResponseFrame(arrayResult(), false).FieldByName("tags")
+	var values []string
+	for i := 0; i < field.Len(); i++ {
+		values = append(values, *field.At(i).(*string))
+	}
+	require.Equal(t, []string{`[]`, `["a","b"]`, `[1,2]`, `[["x"],"y"]`, `["a",1,true,null]`}, values)
+}
+
+func TestArrayHandlingLength(t *testing.T) {
+	result := arrayResult()
+	require.NoError(t, handleArrays(result, arrayHandlingLength))
+	field, _ := newResponseFrame(result, false).FieldByName("tags")
+	var values []int64
+	for i := 0; i < field.Len(); i++ {
+		values = append(values, *field.At(i).(*int64))
+	}
+	require.Equal(t, []int64{0, 2, 2, 2, 4}, values)
+}
+
+func TestArrayHandlingFirst(t *testing.T) {
+	result := arrayResult()
+	require.NoError(t, handleArrays(result, arrayHandlingFirst))
+	require.Equal(t, [][]interface{}{{nil}, {"a"}, {int64(1)}, {[]interface{}{"x"}}, {"a"}}, result.Records)
+}
+
+func TestArrayHandlingUnknown(t *testing.T) {
+	require.Error(t, handleArrays(arrayResult(), "last"))
+}
@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// AuditEvent describes a single Firestore query executed on behalf of a Grafana user.
+// Query holds the FireQL query or, for operations described as JSON such as
+// structured, annotation and variable queries, their JSON model. Resource
+// calls are described by their method and URL.
+type AuditEvent struct {
+	UserID       string
+	OrgID        int64
+	Query        string
+	Collection   string
+	Timestamp    time.Time
+	RowsReturned int
+	Success      bool
+}
+
+// AuditLogger receives an AuditEvent for every query executed against Firestore.
+// Operators can plug in their own implementation with SetAuditLogger to route
+// audit records to an external sink such as a SIEM.
+type AuditLogger interface {
+	Log(ctx context.Context, event AuditEvent)
+}
+
+// NoopAuditLogger discards all audit events. It is the default AuditLogger.
+type NoopAuditLogger struct{}
+
+func (NoopAuditLogger) Log(context.Context, AuditEvent) {}
+
+var (
+	auditLoggerMu     sync.RWMutex
+	globalAuditLogger AuditLogger = NoopAuditLogger{}
+)
+
+// SetAuditLogger replaces the package-level AuditLogger. Passing nil restores
+// the NoopAuditLogger.
+func SetAuditLogger(l AuditLogger) {
+	if l == nil {
+		l = NoopAuditLogger{}
+	}
+	auditLoggerMu.Lock()
+	defer auditLoggerMu.Unlock()
+	globalAuditLogger = l
+}
+
+func auditLogger() AuditLogger {
+	auditLoggerMu.RLock()
+	defer auditLoggerMu.RUnlock()
+	return globalAuditLogger
+}
+
+// logAuditEvent records a query reading or writing collection.
+func logAuditEvent(ctx context.Context, pCtx backend.PluginContext, query, collection string, rows int, err error) {
+	event := AuditEvent{
+		OrgID:        pCtx.OrgID,
+		Query:        query,
+		Collection:   collection,
+		Timestamp:    time.Now(),
+		RowsReturned: rows,
+		Success:      err == nil,
+	}
+	if pCtx.User != nil {
+		event.UserID = pCtx.User.Login
+	}
+	auditLogger().Log(ctx, event)
+}
+
+// logResourceAuditEvent records a resource call reading or writing collection.
+func logResourceAuditEvent(r *http.Request, collection string, rows int, err error) {
+	pCtx := httpadapter.PluginConfigFromContext(r.Context())
+	logAuditEvent(r.Context(), pCtx, r.Method+" "+r.URL.RequestURI(), collection, rows, err)
+}
+
+// auditQuery returns the JSON model of an operation for AuditEvent.Query.
+func auditQuery(model interface{}) string {
+	query, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Sprintf("%+v", model)
+	}
+	return string(query)
+}
+
+// frameRows returns the number of rows of frame, which is nil when the query failed.
+func frameRows(frame *data.Frame) int {
+	if frame == nil {
+		return 0
+	}
+	return frame.Rows()
+}
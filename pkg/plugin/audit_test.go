@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditLogger struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (l *recordingAuditLogger) Log(_ context.Context, event AuditEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func TestAuditLogger(t *testing.T) {
+	logger := &recordingAuditLogger{}
+	SetAuditLogger(logger)
+	defer SetAuditLogger(nil)
+
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+
+	ds := Datasource{}
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID: 7,
+			User:  &backend.User{Login: "auditor"},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				JSONData: jsonSettings,
+			},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"query": "select * from users"}`)},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+
+	require.Len(t, logger.events, 1)
+	event := logger.events[0]
+	require.Equal(t, "auditor", event.UserID)
+	require.Equal(t, int64(7), event.OrgID)
+	require.Equal(t, "select * from users", event.Query)
+	require.Equal(t, "users", event.Collection)
+	require.Equal(t, 5, event.RowsReturned)
+	require.True(t, event.Success)
+	require.False(t, event.Timestamp.IsZero())
+
+	// Structured and annotation queries are logged with their JSON model
+	logger.events = nil
+	resp, err = ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings}},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"structuredQuery": {"Collection": "users", "Limit": 2}}`)},
+			{RefID: "B", QueryType: annotationQueryType, JSON: []byte(`{"collection": "users", "timeField": "createdAt"}`)},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, logger.events, 2)
+	collections := map[string]AuditEvent{}
+	for _, event := range logger.events {
+		require.Equal(t, "users", event.Collection)
+		require.True(t, event.Success)
+		collections[event.Query] = event
+	}
+	require.Equal(t, 2, collections[`{"Collection":"users","Where":null,"OrderBy":null,"Limit":2}`].RowsReturned)
+}
+
+func TestAuditVariableAndResourceCalls(t *testing.T) {
+	logger := &recordingAuditLogger{}
+	SetAuditLogger(logger)
+	defer SetAuditLogger(nil)
+
+	ds := newMockDatasource(&mockExecutor{})
+	ds.listCollections = func(context.Context, backend.PluginContext, string) ([]string, error) {
+		return []string{"stores", "users"}, nil
+	}
+	ds.countBefore = func(context.Context, backend.PluginContext, string, string, time.Time) (int64, error) {
+		return 0, errors.New("permission denied")
+	}
+
+	resp := runVariableQuery(t, ds, `{"mode": "collections"}`)
+	require.NoError(t, resp.Error)
+	callResource(t, ds, FirestoreSettings{ProjectId: "test"}, "Viewer", "GET", "collections", "")
+	callResource(t, ds, FirestoreSettings{ProjectId: "test"}, "Viewer", "GET", "capacity?collection=events", "")
+
+	require.Len(t, logger.events, 3)
+	require.Equal(t, `{"Mode":"collections","Collection":"","Field":"","Filter":""}`, logger.events[0].Query)
+	require.Equal(t, 2, logger.events[0].RowsReturned)
+	require.Equal(t, AuditEvent{UserID: "test", Query: "GET /collections", RowsReturned: 2, Success: true, Timestamp: logger.events[1].Timestamp}, logger.events[1])
+	require.Equal(t, "GET /capacity?collection=events", logger.events[2].Query)
+	require.Equal(t, "events", logger.events[2].Collection)
+	require.False(t, logger.events[2].Success)
+}
+
+func TestExtractCollectionName(t *testing.T) {
+	require.Equal(t, "users", extractCollectionName("select * from users"))
+	require.Equal(t, "users", extractCollectionName("SELECT id FROM `users` WHERE id = 1"))
+	require.Equal(t, "", extractCollectionName("select 1"))
+}
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// Blob encodings for FirestoreQuery.BlobEncoding.
+const (
+	blobEncodingBase64    = "base64"
+	blobEncodingBase64URL = "base64url"
+	blobEncodingHex       = "hex"
+)
+
+const (
+	defaultMaxBlobBytes = 1024
+	truncatedBlobSuffix = "...[truncated]"
+)
+
+// encodeBlobs replaces the Blob values of result with their encoding:
+// "base64" (the default), "base64url" or "hex". Only the first maxBytes
+// bytes (default 1024) of larger blobs are encoded, followed by
+// "...[truncated]".
+func encodeBlobs(result *util.QueryResult, encoding string, maxBytes int) error {
+	var encode func([]byte) string
+	switch encoding {
+	case "", blobEncodingBase64:
+		encode = base64.StdEncoding.EncodeToString
+	case blobEncodingBase64URL:
+		encode = base64.URLEncoding.EncodeToString
+	case blobEncodingHex:
+		encode = hex.EncodeToString
+	default:
+		return fmt.Errorf("unknown blob encoding %q", encoding)
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBlobBytes
+	}
+
+	for _, record := range result.Records {
+		for i, value := range record {
+			blob, ok := value.([]byte)
+			if !ok {
+				continue
+			}
+			if len(blob) > maxBytes {
+				record[i] = encode(blob[:maxBytes]) + truncatedBlobSuffix
+			} else {
+				record[i] = encode(blob)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBlobs(t *testing.T) {
+	for encoding, expected := range map[string]string{
+		"":                    "/+8=",
+		blobEncodingBase64:    "/+8=",
+		blobEncodingBase64URL: "_-8=",
+		blobEncodingHex:       "ffef",
+	} {
+		result := &util.QueryResult{Columns: []string{"data"}, Records: [][]interface{}{{[]byte{0xff, 0xef}}, {nil}}}
+		require.NoError(t, encodeBlobs(result, encoding, 0))
+		require.Equal(t, expected, result.Records[0][0], encoding)
+		require.Nil(t, result.Records[1][0])
+	}
+
+	require.Error(t, encodeBlobs(&util.QueryResult{}, "base32", 0))
+}
+
+func TestEncodeBlobsTruncated(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"data"}, Records: [][]interface{}{{[]byte(strings.Repeat("a", 2000))}}}
+	require.NoError(t, encodeBlobs(result, blobEncodingHex, 0))
+	require.Equal(t, strings.Repeat("61", defaultMaxBlobBytes)+truncatedBlobSuffix, result.Records[0][0])
+
+	result = &util.QueryResult{Columns: []string{"data"}, Records: [][]interface{}{{[]byte("Hello")}}}
+	require.NoError(t, encodeBlobs(result, blobEncodingBase64, 2))
+	require.Equal(t, "SGU="+truncatedBlobSuffix, result.Records[0][0])
+}
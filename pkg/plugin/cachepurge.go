@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+// cachePurgers clears each type of cache kept by the datasource.
+var cachePurgers = map[string]func(d *Datasource){
+	// Results of background polling, incremental refresh and the query
+	// result cache
+	"queries": func(d *Datasource) {
+		d.pollers.clear()
+		clearSyncMap(&d.queryCache)
+		clearSyncMap(&d.incrementalStates)
+		clearSyncMap(&d.loadedRefIDs)
+		clearSyncMap(&d.etags)
+	},
+	// Observed field types and field descriptions
+	"schema": func(d *Datasource) {
+		d.schemas.reset()
+		clearSyncMap(&d.fieldDescriptionsCache)
+		clearSyncMap(&d.externalSchemaCache)
+	},
+	"clients": func(d *Datasource) {
+		d.clients.closeAll()
+	},
+}
+
+// cacheTypes lists the cache types in the order they are cleared by "all".
+var cacheTypes = []string{"queries", "schema", "clients"}
+
+type cachePurgeResponse struct {
+	Cleared []string `json:"cleared"`
+}
+
+// handleCachePurge clears the caches of the type given by the "type" query
+// parameter: queries, schema, clients or all.
+func (d *Datasource) handleCachePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if user := httpadapter.UserFromContext(r.Context()); user == nil || user.Role != "Admin" {
+		writeError(w, http.StatusForbidden, "purging caches requires the Admin role")
+		return
+	}
+
+	cacheType := r.URL.Query().Get("type")
+	types := []string{cacheType}
+	if cacheType == "all" {
+		types = cacheTypes
+	} else if _, ok := cachePurgers[cacheType]; !ok {
+		writeError(w, http.StatusBadRequest, "type must be one of queries, schema, clients or all")
+		return
+	}
+
+	for _, t := range types {
+		cachePurgers[t](d)
+	}
+	log.DefaultLogger.Info("Purged caches", "types", types)
+	writeJSON(w, http.StatusOK, cachePurgeResponse{Cleared: types})
+}
+
+func clearSyncMap(m *sync.Map) {
+	m.Range(func(key, _ interface{}) bool {
+		m.Delete(key)
+		return true
+	})
+}
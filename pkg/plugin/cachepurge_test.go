@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallResourceCachePurge(t *testing.T) {
+	ds := &Datasource{}
+	settings := FirestoreSettings{ProjectId: "test"}
+	fill := func() {
+		ds.incrementalStates.Store("A", &incrementalState{})
+		ds.fieldDescriptionsCache.Store("users", fieldDescriptionsEntry{})
+		ds.schemas.update("users", map[string]string{"age": "int64"})
+	}
+	cached := func() (queries, schema bool) {
+		_, queries = ds.incrementalStates.Load("A")
+		_, schema = ds.fieldDescriptionsCache.Load("users")
+		return queries, schema || len(ds.schemas.collections) > 0
+	}
+
+	fill()
+	resp := callResource(t, ds, settings, "Admin", "DELETE", "cache?type=queries", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	queries, schema := cached()
+	require.False(t, queries)
+	require.True(t, schema)
+
+	fill()
+	resp = callResource(t, ds, settings, "Admin", "DELETE", "cache?type=schema", "")
+	require.Equal(t, 200, resp.Status)
+	queries, schema = cached()
+	require.True(t, queries)
+	require.False(t, schema)
+
+	fill()
+	resp = callResource(t, ds, settings, "Admin", "DELETE", "cache?type=all", "")
+	require.Equal(t, 200, resp.Status)
+	var result cachePurgeResponse
+	require.NoError(t, json.Unmarshal(resp.Body, &result))
+	require.Equal(t, []string{"queries", "schema", "clients"}, result.Cleared)
+	queries, schema = cached()
+	require.False(t, queries)
+	require.False(t, schema)
+}
+
+func TestCallResourceCachePurgeValidation(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := callResource(t, &Datasource{}, settings, "Editor", "DELETE", "cache?type=all", "")
+	require.Equal(t, 403, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Admin", "DELETE", "cache?type=everything", "")
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Admin", "GET", "cache?type=all", "")
+	require.Equal(t, 405, resp.Status)
+}
@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+const (
+	// capacityHistoryDays is the number of daily counts the growth rate is
+	// fitted on, the last one being the current count.
+	capacityHistoryDays      = 7
+	defaultForecastDays      = 30
+	maxForecastDays          = 365
+	defaultCapacityTimeField = "createdAt"
+)
+
+// capacityForecast projects the document count of a collection.
+type capacityForecast struct {
+	Current         int64
+	Forecast        float64
+	ForecastDays    int
+	DailyGrowthRate float64
+}
+
+func (c capacityForecast) response() map[string]interface{} {
+	return map[string]interface{}{
+		"current": c.Current,
+		fmt.Sprintf("forecast%dd", c.ForecastDays): math.Round(c.Forecast),
+		"dailyGrowthRate":                          math.Round(c.DailyGrowthRate*10) / 10,
+	}
+}
+
+// forecastCapacity fits a line through daily counts, oldest first, and
+// extrapolates the last count by forecastDays days of the fitted growth.
+func forecastCapacity(counts []int64, forecastDays int) capacityForecast {
+	n := float64(len(counts))
+	var meanX, meanY float64
+	for i, count := range counts {
+		meanX += float64(i) / n
+		meanY += float64(count) / n
+	}
+	var covariance, variance float64
+	for i, count := range counts {
+		dx := float64(i) - meanX
+		covariance += dx * (float64(count) - meanY)
+		variance += dx * dx
+	}
+	var slope float64
+	if variance > 0 {
+		slope = covariance / variance
+	}
+
+	current := counts[len(counts)-1]
+	return capacityForecast{
+		Current:         current,
+		Forecast:        float64(current) + slope*float64(forecastDays),
+		ForecastDays:    forecastDays,
+		DailyGrowthRate: slope,
+	}
+}
+
+// countDocumentsBefore counts the documents of collection whose timeField is
+// before the given time with a COUNT aggregation.
+func (d *Datasource) countDocumentsBefore(ctx context.Context, pCtx backend.PluginContext, collection, timeField string, before time.Time) (int64, error) {
+	if d.countBefore != nil {
+		return d.countBefore(ctx, pCtx, collection, timeField, before)
+	}
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	ref, err := collectionRef(client, collection)
+	if err != nil {
+		return 0, err
+	}
+	query := ref.Where(timeField, "<", before)
+	result, err := query.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count aggregation result %v", result["count"])
+	}
+	return count.GetIntegerValue(), nil
+}
+
+// handleCapacity projects the document count of the collection given by the
+// "collection" query parameter forecastDays ahead (default 30), from the
+// daily growth of the past week. Documents are dated by timeField (default
+// createdAt). Only COUNT aggregations are run.
+func (d *Datasource) handleCapacity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	params := r.URL.Query()
+	collection := params.Get("collection")
+	if collection == "" {
+		writeError(w, http.StatusBadRequest, "collection is required")
+		return
+	}
+	timeField := params.Get("timeField")
+	if timeField == "" {
+		timeField = defaultCapacityTimeField
+	}
+	forecastDays := defaultForecastDays
+	if params.Has("forecastDays") {
+		var err error
+		forecastDays, err = strconv.Atoi(params.Get("forecastDays"))
+		if err != nil || forecastDays < 1 || forecastDays > maxForecastDays {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("forecastDays must be between 1 and %d", maxForecastDays))
+			return
+		}
+	}
+
+	pCtx := httpadapter.PluginConfigFromContext(r.Context())
+	now := time.Now()
+	counts := make([]int64, capacityHistoryDays)
+	for i := range counts {
+		before := now.AddDate(0, 0, i-(capacityHistoryDays-1))
+		count, err := d.countDocumentsBefore(r.Context(), pCtx, collection, timeField, before)
+		if err != nil {
+			logResourceAuditEvent(r, collection, 0, err)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		counts[i] = count
+	}
+	logResourceAuditEvent(r, collection, 0, nil)
+	writeJSON(w, http.StatusOK, forecastCapacity(counts, forecastDays).response())
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallResourceCapacity(t *testing.T) {
+	// 9000 documents a week ago, growing by about 166.7 a day
+	daily := []int64{9000, 9160, 9340, 9500, 9670, 9830, 10000}
+	var calls []time.Time
+	ds := &Datasource{countBefore: func(_ context.Context, _ backend.PluginContext, collection, timeField string, before time.Time) (int64, error) {
+		require.Equal(t, "events", collection)
+		require.Equal(t, "timestamp", timeField)
+		calls = append(calls, before)
+		return daily[len(calls)-1], nil
+	}}
+
+	resp := callResource(t, ds, FirestoreSettings{ProjectId: "test"}, "Viewer", "GET", "capacity?collection=events&forecastDays=30&timeField=timestamp", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	require.Len(t, calls, capacityHistoryDays)
+	require.WithinDuration(t, time.Now(), calls[6], time.Minute)
+	require.WithinDuration(t, time.Now().AddDate(0, 0, -6), calls[0], time.Minute)
+
+	var result map[string]float64
+	require.NoError(t, json.Unmarshal(resp.Body, &result))
+	require.Equal(t, 10000.0, result["current"])
+	require.InEpsilon(t, 166.7, result["dailyGrowthRate"], 0.05)
+	require.InEpsilon(t, 15000.0, result["forecast30d"], 0.05)
+}
+
+func TestForecastCapacity(t *testing.T) {
+	forecast := forecastCapacity([]int64{100, 200, 300, 400, 500, 600, 700}, 10)
+	require.Equal(t, int64(700), forecast.Current)
+	require.InDelta(t, 100, forecast.DailyGrowthRate, 1e-9)
+	require.InDelta(t, 1700, forecast.Forecast, 1e-9)
+
+	forecast = forecastCapacity([]int64{5}, 30)
+	require.Equal(t, 5.0, forecast.Forecast)
+}
+
+func TestCallResourceCapacityValidation(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := callResource(t, &Datasource{}, settings, "Viewer", "GET", "capacity", "")
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Viewer", "GET", "capacity?collection=events&forecastDays=0", "")
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Viewer", "POST", "capacity?collection=events", "")
+	require.Equal(t, 405, resp.Status)
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const defaultMaxCachedClients = 10
+
+// clientCache keeps Firestore clients by project, database and service account
+// so they are reused across queries. When more than the configured number of
+// clients are cached, the least recently used one is evicted. Evicted and
+// purged clients are closed once the last user released them, so pollers,
+// streams and running queries keep a working client. The zero value is ready
+// to use.
+type clientCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds *clientCacheEntry values, most recently used first.
+	lru *list.List
+}
+
+type clientCacheEntry struct {
+	key    string
+	client *firestore.Client
+	// refs is the number of users that have not released the client yet.
+	refs int
+	// removed is set once the client left the cache; it is closed when refs
+	// drops to zero.
+	removed bool
+}
+
+// get returns the client cached under key, creating it with create if needed,
+// and a function releasing it. The function must be called once the client is
+// no longer used.
+func (c *clientCache) get(key string, maxClients int, create func() (*firestore.Client, error)) (*firestore.Client, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.lru = list.New()
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*clientCacheEntry)
+		return entry.client, c.acquire(entry), nil
+	}
+
+	client, err := create()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry := &clientCacheEntry{key: key, client: client}
+	c.entries[key] = c.lru.PushFront(entry)
+	release := c.acquire(entry)
+
+	if maxClients <= 0 {
+		maxClients = defaultMaxCachedClients
+	}
+	for c.lru.Len() > maxClients {
+		oldest := c.lru.Remove(c.lru.Back()).(*clientCacheEntry)
+		delete(c.entries, oldest.key)
+		log.DefaultLogger.Debug("Evicting cached Firestore client", "key", oldest.key)
+		c.remove(oldest)
+	}
+	return client, release, nil
+}
+
+// acquire counts a new user of entry and returns the function releasing it.
+// c.mu must be held.
+func (c *clientCache) acquire(entry *clientCacheEntry) func() {
+	entry.refs++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			entry.refs--
+			if entry.removed && entry.refs == 0 {
+				closeCachedClient(entry)
+			}
+		})
+	}
+}
+
+// remove marks entry as no longer cached, closing its client unless it is
+// still in use. c.mu must be held.
+func (c *clientCache) remove(entry *clientCacheEntry) {
+	entry.removed = true
+	if entry.refs == 0 {
+		closeCachedClient(entry)
+	}
+}
+
+func closeCachedClient(entry *clientCacheEntry) {
+	if err := entry.client.Close(); err != nil {
+		log.DefaultLogger.Warn("Closing cached Firestore client", "key", entry.key, "error", err)
+	}
+}
+
+// closeAll forgets every cached client. Clients are closed once their users
+// released them.
+func (c *clientCache) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, elem := range c.entries {
+		c.remove(elem.Value.(*clientCacheEntry))
+	}
+	c.entries = nil
+	c.lru = nil
+}
+
+// firestoreClient returns the cached Firestore client for the datasource's
+// project and database. With weighted service accounts, an account is picked on
+// every call and each account gets its own client. The client is owned by the
+// cache and must not be closed; the returned function releases it instead.
+func (d *Datasource) firestoreClient(pCtx backend.PluginContext) (*firestore.Client, func(), error) {
+	var settings FirestoreSettings
+	if err := json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings); err != nil {
+		return nil, nil, fmt.Errorf("ProjectID: %v", err)
+	}
+	if len(settings.ProjectId) == 0 {
+		settings.ProjectId = projectIDFromEnvironment()
+	}
+
+	serviceAccount := selectServiceAccount(settings, pCtx)
+	key := settings.ProjectId + "/" + settings.DatabaseName
+	if serviceAccount != "" {
+		// The key is logged on eviction, so it holds a digest rather than the key material
+		digest := sha256.Sum256([]byte(serviceAccount))
+		key += fmt.Sprintf("/%x", digest[:8])
+	}
+	return d.clients.get(key, settings.MaxCachedClients, func() (*firestore.Client, error) {
+		// Cached clients outlive the request, so they must not be bound to its context.
+		return newServiceAccountClient(context.Background(), pCtx, serviceAccount)
+	})
+}
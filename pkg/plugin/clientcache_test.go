@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatasourceFirestoreClientCache(t *testing.T) {
+	t.Setenv(FirestoreEmulatorHost, "localhost:8765")
+
+	pluginContext := func(databaseName string) backend.PluginContext {
+		jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test", DatabaseName: databaseName})
+		require.NoError(t, err)
+		return backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		}
+	}
+
+	ds := Datasource{}
+	defer ds.Dispose()
+
+	first, _, err := ds.firestoreClient(pluginContext("orders"))
+	require.NoError(t, err)
+	second, _, err := ds.firestoreClient(pluginContext("orders"))
+	require.NoError(t, err)
+	require.Same(t, first, second)
+
+	other, _, err := ds.firestoreClient(pluginContext("inventory"))
+	require.NoError(t, err)
+	require.NotSame(t, first, other)
+}
+
+func TestClientCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Setenv(FirestoreEmulatorHost, "localhost:8765")
+
+	created := 0
+	create := func() (*firestore.Client, error) {
+		created++
+		return newFirestoreTestClient(context.Background()), nil
+	}
+
+	var cache clientCache
+	defer cache.closeAll()
+
+	a, _, _ := cache.get("test/a", 2, create)
+	_, _, _ = cache.get("test/b", 2, create)
+	again, _, _ := cache.get("test/a", 2, create)
+	require.Same(t, a, again)
+
+	// "test/b" is now the least recently used client and is evicted
+	_, _, _ = cache.get("test/c", 2, create)
+	_, _, _ = cache.get("test/b", 2, create)
+	require.Equal(t, 4, created)
+	_, _, _ = cache.get("test/a", 2, create)
+	require.Equal(t, 5, created)
+}
+
+func TestDatasourceFirestoreClientPerServiceAccount(t *testing.T) {
+	t.Setenv(FirestoreEmulatorHost, "localhost:8765")
+
+	// Alternate between the two accounts on every draw
+	next := 0
+	serviceAccountIntn = func(n int) int {
+		defer func() { next++ }()
+		return next % n
+	}
+	defer func() { serviceAccountIntn = rand.Intn }()
+
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test", ServiceAccounts: []WeightedServiceAccount{
+		{JSON: `{"client_email": "a@test"}`, Weight: 1},
+		{JSON: `{"client_email": "b@test"}`, Weight: 1},
+	}})
+	require.NoError(t, err)
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings}}
+
+	ds := Datasource{}
+	defer ds.Dispose()
+
+	counts := map[*firestore.Client]int{}
+	for i := 0; i < 10; i++ {
+		client, release, err := ds.firestoreClient(pCtx)
+		require.NoError(t, err)
+		release()
+		counts[client]++
+	}
+	require.Len(t, counts, 2)
+	for _, count := range counts {
+		require.Equal(t, 5, count)
+	}
+	require.Equal(t, 2, ds.clients.lru.Len())
+}
+
+func TestClientCacheKeepsClientsInUse(t *testing.T) {
+	t.Setenv(FirestoreEmulatorHost, "localhost:8765")
+	create := func() (*firestore.Client, error) {
+		return newFirestoreTestClient(context.Background()), nil
+	}
+
+	var cache clientCache
+	evicted, releaseEvicted, err := cache.get("test/a", 1, create)
+	require.NoError(t, err)
+	_, releaseB, err := cache.get("test/b", 1, create)
+	require.NoError(t, err)
+	purged, _, err := cache.get("test/b", 1, create)
+	require.NoError(t, err)
+	releaseB()
+	cache.closeAll()
+
+	// Closing a client twice fails, so a successful Close shows that the
+	// cache kept the client in use open
+	require.NoError(t, purged.Close())
+
+	releaseEvicted()
+	releaseEvicted()
+	require.Error(t, evicted.Close())
+}
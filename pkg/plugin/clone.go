@@ -0,0 +1,178 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"golang.org/x/time/rate"
+)
+
+const (
+	maxCloneDocuments     = 10000
+	defaultCloneBatchSize = 100
+	// maxCloneBatchSize is the number of writes Firestore accepts per commit.
+	maxCloneBatchSize = 500
+	defaultCloneQPS   = 100
+)
+
+type cloneRequest struct {
+	SourceQuery      string
+	TargetCollection string
+	// WriteBatchSize is the number of writes flushed at once (default 100,
+	// at most 500) and WriteQPS the maximum writes per second (default 100).
+	WriteBatchSize int
+	WriteQPS       float64
+	// BatchSize is the former name of WriteBatchSize, still accepted.
+	BatchSize int `json:"batchSize"`
+}
+
+type cloneResponse struct {
+	Cloned  int `json:"cloned"`
+	Errors  int `json:"errors"`
+	Batches int `json:"batches"`
+}
+
+// handleClone copies the documents matching a query into another collection,
+// keeping their IDs. At most maxCloneDocuments documents are copied.
+func (d *Datasource) handleClone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !canWrite(r) {
+		writeError(w, http.StatusForbidden, "cloning documents requires the Editor or Admin role")
+		return
+	}
+
+	pCtx := httpadapter.PluginConfigFromContext(r.Context())
+	var settings FirestoreSettings
+	if err := json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !settings.WriteEnabled {
+		writeError(w, http.StatusForbidden, "cloning documents requires WriteEnabled")
+		return
+	}
+
+	var req cloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if req.TargetCollection == "" {
+		writeError(w, http.StatusBadRequest, "targetCollection is required")
+		return
+	}
+	if req.WriteBatchSize == 0 {
+		req.WriteBatchSize = req.BatchSize
+	}
+	if req.WriteBatchSize == 0 {
+		req.WriteBatchSize = defaultCloneBatchSize
+	}
+	if req.WriteBatchSize < 0 || req.WriteBatchSize > maxCloneBatchSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("writeBatchSize must be between 1 and %d", maxCloneBatchSize))
+		return
+	}
+	if req.WriteQPS == 0 {
+		req.WriteQPS = defaultCloneQPS
+	}
+	if req.WriteQPS < 0 {
+		writeError(w, http.StatusBadRequest, "writeQPS must be positive")
+		return
+	}
+	stmt, err := parseSelectStatement(req.SourceQuery)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "sourceQuery: "+err.Error())
+		return
+	}
+	if stmt.Limit <= 0 || stmt.Limit > maxCloneDocuments {
+		stmt.Limit = maxCloneDocuments
+	}
+
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer release()
+	if _, err := collectionRef(client, req.TargetCollection); err != nil {
+		writeError(w, http.StatusBadRequest, "targetCollection: "+err.Error())
+		return
+	}
+	q, err := stmt.firestoreQuery(client)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "sourceQuery: "+err.Error())
+		return
+	}
+	docs, err := q.Documents(r.Context()).GetAll()
+	logResourceAuditEvent(r, stmt.Collection, len(docs), err)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "sourceQuery: "+err.Error())
+		return
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(req.WriteQPS), 1)
+	resp := cloneDocuments(r.Context(), client, docs, req.TargetCollection, req.WriteBatchSize, limiter)
+	var writeErr error
+	if resp.Errors > 0 {
+		writeErr = fmt.Errorf("%d documents failed to clone", resp.Errors)
+	}
+	logResourceAuditEvent(r, req.TargetCollection, resp.Cloned, writeErr)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// cloneDocuments writes docs to the target collection with a BulkWriter,
+// flushing every batchSize documents and throttling writes with limiter.
+// Failed writes are counted as errors.
+func cloneDocuments(ctx context.Context, client *firestore.Client, docs []*firestore.DocumentSnapshot, target string, batchSize int, limiter *rate.Limiter) cloneResponse {
+	if len(docs) == 0 {
+		return cloneResponse{}
+	}
+	writer := client.BulkWriter(ctx)
+	defer writer.End()
+
+	var resp cloneResponse
+	jobs := make([]*firestore.BulkWriterJob, 0, batchSize)
+	wait := func() {
+		if len(jobs) == 0 {
+			return
+		}
+		writer.Flush()
+		resp.Batches++
+		for _, job := range jobs {
+			if _, err := job.Results(); err != nil {
+				log.DefaultLogger.Warn("Cloning document", "error", err)
+				resp.Errors++
+			} else {
+				resp.Cloned++
+			}
+		}
+		jobs = jobs[:0]
+	}
+
+	for i, doc := range docs {
+		if err := limiter.Wait(ctx); err != nil {
+			log.DefaultLogger.Warn("Cloning documents interrupted", "error", err)
+			resp.Errors += len(docs) - i
+			break
+		}
+		job, err := writer.Set(client.Collection(target).Doc(doc.Ref.ID), doc.Data())
+		if err != nil {
+			log.DefaultLogger.Warn("Cloning document", "id", doc.Ref.ID, "error", err)
+			resp.Errors++
+			continue
+		}
+		jobs = append(jobs, job)
+		if len(jobs) == batchSize {
+			wait()
+		}
+	}
+	wait()
+	return resp
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallResourceClone(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for id, status := range map[string]string{"o1": "closed", "o2": "closed", "o3": "closed", "o4": "open"} {
+		_, err := client.Collection("clone_orders").Doc(id).Set(ctx, map[string]interface{}{"status": status})
+		require.NoError(t, err)
+	}
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	resp := callResource(t, ds, FirestoreSettings{ProjectId: "test", WriteEnabled: true}, "Editor", "POST", "clone",
+		`{"sourceQuery": "select * from clone_orders where status = 'closed'", "targetCollection": "archive_orders", "writeBatchSize": 2}`)
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+
+	var result cloneResponse
+	require.NoError(t, json.Unmarshal(resp.Body, &result))
+	require.Equal(t, cloneResponse{Cloned: 3, Batches: 2}, result)
+
+	docs, err := client.Collection("archive_orders").Documents(ctx).GetAll()
+	require.NoError(t, err)
+	var ids []string
+	for _, doc := range docs {
+		ids = append(ids, doc.Ref.ID)
+		require.Equal(t, "closed", doc.Data()["status"])
+	}
+	require.ElementsMatch(t, []string{"o1", "o2", "o3"}, ids)
+}
+
+func TestCallResourceCloneBatches(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for i := 0; i < 250; i++ {
+		_, err := client.Collection("clone_events").Doc(fmt.Sprintf("e%03d", i)).Set(ctx, map[string]interface{}{"n": i})
+		require.NoError(t, err)
+	}
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	resp := callResource(t, ds, FirestoreSettings{ProjectId: "test", WriteEnabled: true}, "Editor", "POST", "clone",
+		`{"sourceQuery": "select * from clone_events", "targetCollection": "archive_events", "writeBatchSize": 100, "writeQPS": 500}`)
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+
+	var result cloneResponse
+	require.NoError(t, json.Unmarshal(resp.Body, &result))
+	require.Equal(t, cloneResponse{Cloned: 250, Batches: 3}, result)
+}
+
+func TestCallResourceCloneValidation(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test", WriteEnabled: true}
+	body := `{"sourceQuery": "select * from orders", "targetCollection": "archive"}`
+
+	resp := callResource(t, &Datasource{}, settings, "Viewer", "POST", "clone", body)
+	require.Equal(t, 403, resp.Status)
+
+	resp = callResource(t, &Datasource{}, FirestoreSettings{ProjectId: "test"}, "Editor", "POST", "clone", body)
+	require.Equal(t, 403, resp.Status)
+	require.Contains(t, string(resp.Body), "WriteEnabled")
+
+	resp = callResource(t, &Datasource{}, settings, "Editor", "GET", "clone", body)
+	require.Equal(t, 405, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Editor", "POST", "clone", `{"sourceQuery": "select * from orders"}`)
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Editor", "POST", "clone", `{"sourceQuery": "delete from orders", "targetCollection": "archive"}`)
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Editor", "POST", "clone", `{"sourceQuery": "select * from orders", "targetCollection": "archive", "writeBatchSize": 501}`)
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Editor", "POST", "clone", `{"sourceQuery": "select * from orders", "targetCollection": "archive", "batchSize": 501}`)
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Editor", "POST", "clone", `{"sourceQuery": "select * from orders", "targetCollection": "archive", "writeQPS": -1}`)
+	require.Equal(t, 400, resp.Status)
+}
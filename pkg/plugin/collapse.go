@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const defaultCollapseAggregation = "concat"
+
+// collapseByField collapses the rows sharing the same value of field into
+// one row, in order of first appearance. Numeric fields are aggregated with
+// "sum", "avg", "min" or "max", while other fields, and every field with the
+// default "concat" aggregation, join their values with commas. "count"
+// replaces every field by the number of its non-nil values.
+func collapseByField(frame *data.Frame, field, aggregation string) (*data.Frame, error) {
+	if aggregation == "" {
+		aggregation = defaultCollapseAggregation
+	}
+	switch aggregation {
+	case "sum", "avg", "min", "max", "count", "concat":
+	default:
+		return nil, fmt.Errorf("unsupported collapse aggregation %q", aggregation)
+	}
+	keyField, _ := frame.FieldByName(field)
+	if keyField == nil {
+		return nil, fmt.Errorf("collapse field %q not found", field)
+	}
+
+	rows := groupRows(keyField.Len(), keyField.At)
+	firstRows := make([]int, len(rows))
+	for i, group := range rows {
+		firstRows[i] = group[0]
+	}
+
+	out := data.NewFrame(frame.Name)
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	for _, f := range frame.Fields {
+		var collapsed *data.Field
+		switch {
+		case f == keyField:
+			collapsed = selectRows(data.NewFrame("", f), firstRows).Fields[0]
+		case aggregation == "count":
+			counts := make([]*int64, len(rows))
+			for i, group := range rows {
+				n := int64(0)
+				for _, row := range group {
+					if _, ok := f.ConcreteAt(row); ok {
+						n++
+					}
+				}
+				counts[i] = &n
+			}
+			collapsed = data.NewField(f.Name, f.Labels, counts)
+		case aggregation != "concat" && f.Type().Numeric():
+			values := make([]*float64, len(rows))
+			for i, group := range rows {
+				v, err := aggregateFloats(group, f.NullableFloatAt, aggregation)
+				if err != nil {
+					return nil, err
+				}
+				values[i] = v
+			}
+			collapsed = data.NewField(f.Name, f.Labels, values)
+		default:
+			values := make([]*string, len(rows))
+			for i, group := range rows {
+				var parts []string
+				for _, row := range group {
+					if v, ok := f.ConcreteAt(row); ok {
+						parts = append(parts, stringifyValue(v))
+					}
+				}
+				if parts != nil {
+					joined := strings.Join(parts, ",")
+					values[i] = &joined
+				}
+			}
+			collapsed = data.NewField(f.Name, f.Labels, values)
+		}
+		collapsed.Config = f.Config
+		out.Fields = append(out.Fields, collapsed)
+	}
+	return out, nil
+}
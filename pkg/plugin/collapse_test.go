@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func newCollapseTestFrame() *data.Frame {
+	hosts := []string{"a", "b", "c", "a", "b", "a", "c", "a", "b", "a"}
+	hostValues := make([]*string, len(hosts))
+	for i := range hosts {
+		hostValues[i] = &hosts[i]
+	}
+	return data.NewFrame("response",
+		data.NewField("host", nil, hostValues),
+		data.NewField("bytes", nil, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}),
+		data.NewField("region", nil, []string{"eu", "us", "eu", "eu", "us", "eu", "ap", "eu", "us", "eu"}),
+	)
+}
+
+func TestCollapseByFieldSum(t *testing.T) {
+	frame, err := collapseByField(newCollapseTestFrame(), "host", "sum")
+	require.NoError(t, err)
+	require.Equal(t, 3, frame.Rows())
+
+	expected := [][]interface{}{
+		{"a", 1.0 + 4 + 6 + 8 + 10, "eu,eu,eu,eu,eu"},
+		{"b", 2.0 + 5 + 9, "us,us,us"},
+		{"c", 3.0 + 7, "eu,ap"},
+	}
+	for i, want := range expected {
+		require.Equal(t, want[0], *frame.Fields[0].At(i).(*string))
+		require.Equal(t, want[1], *frame.Fields[1].At(i).(*float64))
+		require.Equal(t, want[2], *frame.Fields[2].At(i).(*string))
+	}
+}
+
+func TestCollapseByFieldAggregations(t *testing.T) {
+	frame, err := collapseByField(newCollapseTestFrame(), "host", "count")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), *frame.Fields[1].At(0).(*int64))
+	require.Equal(t, int64(2), *frame.Fields[2].At(2).(*int64))
+
+	frame, err = collapseByField(newCollapseTestFrame(), "host", "")
+	require.NoError(t, err)
+	require.Equal(t, "3,7", *frame.Fields[1].At(2).(*string))
+
+	frame, err = collapseByField(newCollapseTestFrame(), "host", "max")
+	require.NoError(t, err)
+	require.Equal(t, 9.0, *frame.Fields[1].At(1).(*float64))
+
+	_, err = collapseByField(newCollapseTestFrame(), "host", "median")
+	require.Error(t, err)
+	_, err = collapseByField(newCollapseTestFrame(), "missing", "sum")
+	require.Error(t, err)
+}
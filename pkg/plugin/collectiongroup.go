@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// collectionGroupQueryType is the query type running the query over the
+	// collection group of its FROM collection.
+	collectionGroupQueryType = "collectionGroup"
+
+	collectionPathField = "__collection_path"
+)
+
+var (
+	collectionGroupPattern = regexp.MustCompile("(?i)\\bcollection_group\\(\\s*['\"]([^'\"]+)['\"]\\s*\\)")
+	fromCollectionPattern  = regexp.MustCompile("(?i)(\\bFROM\\s+)`?([\\w-]+)`?(\\s|;|$)")
+)
+
+// rewriteCollectionGroups rewrites FROM collection_group('name') clauses of
+// query into the FireQL collection group syntax, FROM `[name]`. With the
+// collectionGroup query type, a plain FROM collection is rewritten as well.
+func rewriteCollectionGroups(query, queryType string) string {
+	query = collectionGroupPattern.ReplaceAllString(query, "`[$1]`")
+	if queryType == collectionGroupQueryType {
+		query = fromCollectionPattern.ReplaceAllString(query, "$1`[$2]`$3")
+	}
+	return query
+}
+
+// documentsPath returns path relative to the documents of its database, e.g.
+// users/abc/orders for projects/p/databases/(default)/documents/users/abc/orders.
+func documentsPath(path string) string {
+	if i := strings.Index(path, "/documents/"); i >= 0 {
+		return path[i+len("/documents/"):]
+	}
+	return path
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteCollectionGroups(t *testing.T) {
+	tests := []struct {
+		query, queryType, expected string
+	}{
+		{"select * from users", "", "select * from users"},
+		{"select * from collection_group('orders') where total > 10", "", "select * from `[orders]` where total > 10"},
+		{`SELECT id FROM COLLECTION_GROUP( "orders" )`, "", "SELECT id FROM `[orders]`"},
+		{"select * from orders order by total", collectionGroupQueryType, "select * from `[orders]` order by total"},
+		{"select * from `orders`", collectionGroupQueryType, "select * from `[orders]`"},
+		{"select * from collection_group('orders')", collectionGroupQueryType, "select * from `[orders]`"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, rewriteCollectionGroups(test.query, test.queryType), test.query)
+	}
+}
+
+func TestDocumentsPath(t *testing.T) {
+	require.Equal(t, "users/abc/orders", documentsPath("projects/p/databases/(default)/documents/users/abc/orders"))
+	require.Equal(t, "orders", documentsPath("orders"))
+}
+
+func TestCollectionGroupQueryType(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}}}}
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+
+	_, err = newMockDatasource(mock).QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Queries: []backend.DataQuery{{RefID: "A", QueryType: collectionGroupQueryType, JSON: []byte(`{"query": "select id from orders"}`)}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"select id from `[orders]`"}, mock.queries)
+}
+
+func TestEmulatorCollectionGroup(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for _, user := range []string{"alice", "bob"} {
+		_, err := client.Collection("group_users").Doc(user).Collection("group_orders").Doc("o1").Set(ctx, map[string]interface{}{"total": 10})
+		require.NoError(t, err)
+	}
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, `{"query": "select total from collection_group('group_orders')"}`)
+	require.NoError(t, resp.Error)
+	paths, _ := resp.Frames[0].FieldByName(collectionPathField)
+	require.NotNil(t, paths)
+	require.Equal(t, 2, paths.Len())
+	require.Equal(t, "group_users/alice/group_orders", *paths.At(0).(*string))
+}
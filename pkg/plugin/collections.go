@@ -0,0 +1,231 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// maxCollectionListingDepth caps the depth parameter of GET /collections.
+	maxCollectionListingDepth = 3
+	// collectionSampleSize is the number of documents returned by
+	// GET /collections/{name}/sample.
+	collectionSampleSize = 5
+)
+
+// collectionNode is a collection of the tree returned by GET /collections.
+type collectionNode struct {
+	Name           string           `json:"name"`
+	Subcollections []collectionNode `json:"subcollections,omitempty"`
+}
+
+// collectionRef returns the collection at path. It returns an error rather
+// than a nil reference when path is not a collection path, such as the
+// document path users/abc.
+func collectionRef(client *firestore.Client, path string) (*firestore.CollectionRef, error) {
+	ref := client.Collection(path)
+	if ref == nil {
+		return nil, fmt.Errorf("%q is not a collection path", path)
+	}
+	return ref, nil
+}
+
+// collectionPaths returns the paths of the collections under parent, a
+// collection path, or of the root collections when parent is empty. The
+// subcollections of a collection are those of one sample document.
+func (d *Datasource) collectionPaths(ctx context.Context, pCtx backend.PluginContext, parent string) ([]string, error) {
+	if d.listCollections != nil {
+		return d.listCollections(ctx, pCtx, parent)
+	}
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	it := client.Collections(ctx)
+	prefix := ""
+	if parent != "" {
+		ref, err := collectionRef(client, parent)
+		if err != nil {
+			return nil, err
+		}
+		docs, err := ref.Limit(1).Documents(ctx).GetAll()
+		if err != nil || len(docs) == 0 {
+			return nil, err
+		}
+		it = docs[0].Ref.Collections(ctx)
+		prefix = parent + "/" + docs[0].Ref.ID + "/"
+	}
+
+	var paths []string
+	for {
+		ref, err := it.Next()
+		if err == iterator.Done {
+			return paths, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, prefix+ref.ID)
+	}
+}
+
+// collectionTree returns the collections under parent and, down to depth
+// levels, their subcollections.
+func (d *Datasource) collectionTree(ctx context.Context, pCtx backend.PluginContext, parent string, depth int) ([]collectionNode, error) {
+	paths, err := d.collectionPaths(ctx, pCtx, parent)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]collectionNode, len(paths))
+	for i, p := range paths {
+		nodes[i].Name = path.Base(p)
+		if depth > 1 {
+			nodes[i].Subcollections, err = d.collectionTree(ctx, pCtx, p, depth-1)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// handleCollections lists the root collections as a JSON array of names or,
+// with the depth parameter, as a tree including subcollections down to depth
+// levels (at most maxCollectionListingDepth).
+func (d *Datasource) handleCollections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	pCtx := httpadapter.PluginConfigFromContext(r.Context())
+
+	if r.URL.Query().Has("depth") {
+		depth, err := strconv.Atoi(r.URL.Query().Get("depth"))
+		if err != nil || depth < 1 || depth > maxCollectionListingDepth {
+			writeError(w, http.StatusBadRequest, "depth must be between 1 and "+strconv.Itoa(maxCollectionListingDepth))
+			return
+		}
+		tree, err := d.collectionTree(r.Context(), pCtx, "", depth)
+		logResourceAuditEvent(r, "", len(tree), err)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, tree)
+		return
+	}
+
+	paths, err := d.collectionPaths(r.Context(), pCtx, "")
+	logResourceAuditEvent(r, "", len(paths), err)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if paths == nil {
+		paths = []string{}
+	}
+	writeJSON(w, http.StatusOK, paths)
+}
+
+type sampleDocument struct {
+	ID   string                 `json:"id"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// handleCollectionDocuments serves GET /collections/{name}/fields, the
+// inferred type of the fields of a sample of the collection's documents by
+// field name, and GET /collections/{name}/sample, a few of its documents.
+func (d *Datasource) handleCollectionDocuments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	collection, action := path.Split(strings.TrimPrefix(r.URL.Path, "/collections/"))
+	collection = strings.TrimSuffix(collection, "/")
+	limit := schemaSampleSize
+	switch {
+	case collection == "":
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case action == "sample":
+		limit = collectionSampleSize
+	case action != "fields":
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	client, release, err := d.firestoreClient(httpadapter.PluginConfigFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer release()
+	ref, err := collectionRef(client, collection)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	snapshots, err := ref.Limit(limit).Documents(r.Context()).GetAll()
+	logResourceAuditEvent(r, collection, len(snapshots), err)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if action == "sample" {
+		docs := make([]sampleDocument, len(snapshots))
+		for i, snapshot := range snapshots {
+			docs[i] = sampleDocument{ID: snapshot.Ref.ID, Data: sampleValue(snapshot.Data()).(map[string]interface{})}
+		}
+		writeJSON(w, http.StatusOK, docs)
+		return
+	}
+
+	docs := make([]map[string]interface{}, len(snapshots))
+	for i, snapshot := range snapshots {
+		docs[i] = snapshot.Data()
+	}
+	fields, err := inferSchema(r.Context(), docs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	types := make(map[string]string, len(fields))
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+	writeJSON(w, http.StatusOK, types)
+}
+
+// sampleValue returns v with the document references it holds replaced by
+// their path, so that it can be encoded as JSON.
+func sampleValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *firestore.DocumentRef:
+		return v.Path
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[key] = sampleValue(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = sampleValue(value)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func newCollectionsTestDatasource() *Datasource {
+	hierarchy := map[string][]string{
+		"":                         {"orders", "users"},
+		"users":                    {"users/u1/addresses", "users/u1/orders"},
+		"users/u1/orders":          {"users/u1/orders/o1/items"},
+		"users/u1/orders/o1/items": {"users/u1/orders/o1/items/i1/notes"},
+	}
+	return &Datasource{
+		listCollections: func(_ context.Context, _ backend.PluginContext, parent string) ([]string, error) {
+			return hierarchy[parent], nil
+		},
+	}
+}
+
+func TestCallResourceCollections(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := callResource(t, newCollectionsTestDatasource(), settings, "Viewer", "GET", "collections", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	require.JSONEq(t, `["orders", "users"]`, string(resp.Body))
+
+	resp = callResource(t, newCollectionsTestDatasource(), settings, "Viewer", "GET", "collections?depth=2", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	var tree []collectionNode
+	require.NoError(t, json.Unmarshal(resp.Body, &tree))
+	require.Equal(t, []collectionNode{
+		{Name: "orders"},
+		{Name: "users", Subcollections: []collectionNode{{Name: "addresses"}, {Name: "orders"}}},
+	}, tree)
+
+	resp = callResource(t, newCollectionsTestDatasource(), settings, "Viewer", "GET", "collections?depth=3", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	require.JSONEq(t, `[
+		{"name": "orders"},
+		{"name": "users", "subcollections": [
+			{"name": "addresses"},
+			{"name": "orders", "subcollections": [{"name": "items"}]}
+		]}
+	]`, string(resp.Body))
+}
+
+func TestCallResourceCollectionsValidation(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	for _, depth := range []string{"0", "4", "deep"} {
+		resp := callResource(t, newCollectionsTestDatasource(), settings, "Viewer", "GET", "collections?depth="+depth, "")
+		require.Equal(t, 400, resp.Status, depth)
+	}
+
+	resp := callResource(t, newCollectionsTestDatasource(), settings, "Viewer", "POST", "collections", "")
+	require.Equal(t, 405, resp.Status)
+}
+
+func TestCallResourceCollectionsRateLimit(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+	ds := newCollectionsTestDatasource()
+
+	for i := 0; i < discoveryRequestsPerMinute; i++ {
+		resp := callResource(t, ds, settings, "Viewer", "GET", "collections", "")
+		require.Equal(t, 200, resp.Status)
+	}
+	for _, path := range []string{"collections/users/fields", "schema?collection=users", "capacity?collection=users"} {
+		resp := callResource(t, ds, settings, "Viewer", "GET", path, "")
+		require.Equal(t, 429, resp.Status, path)
+	}
+
+	resp := callResource(t, newCollectionsTestDatasource(), settings, "Viewer", "GET", "collections/users/documents", "")
+	require.Equal(t, 404, resp.Status)
+}
+
+func TestCallResourceCollectionDocuments(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for i, name := range []string{"Leanne", "Ervin", "Clementine", "Patricia", "Chelsey", "Dennis"} {
+		_, err := client.Collection("discovery_users").Doc(name).Set(ctx, map[string]interface{}{
+			"name":    name,
+			"age":     int64(30 + i),
+			"manager": client.Doc("discovery_users/Leanne"),
+		})
+		require.NoError(t, err)
+	}
+	settings := FirestoreSettings{ProjectId: "test"}
+	ds := &Datasource{}
+	defer ds.Dispose()
+
+	resp := callResource(t, ds, settings, "Viewer", "GET", "collections/discovery_users/fields", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	require.JSONEq(t, `{"name": "string", "age": "int64", "manager": "*firestore.DocumentRef"}`, string(resp.Body))
+
+	resp = callResource(t, ds, settings, "Viewer", "GET", "collections/discovery_users/sample", "")
+	require.Equal(t, 200, resp.Status, string(resp.Body))
+	var docs []sampleDocument
+	require.NoError(t, json.Unmarshal(resp.Body, &docs))
+	require.Len(t, docs, collectionSampleSize)
+	require.Contains(t, docs[0].Data["manager"], "discovery_users/Leanne")
+}
+
+func TestCallResourceDocumentPathRejected(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+	ds := &Datasource{}
+	defer ds.Dispose()
+
+	resp := callResource(t, ds, settings, "Viewer", "GET", "collections/users/abc/fields", "")
+	require.Equal(t, 400, resp.Status, string(resp.Body))
+	resp = callResource(t, ds, settings, "Viewer", "GET", "collections/users/abc/sample", "")
+	require.Equal(t, 400, resp.Status, string(resp.Body))
+}
+
+func TestCollectionRef(t *testing.T) {
+	client := newFirestoreTestClient(context.Background())
+	defer client.Close()
+
+	ref, err := collectionRef(client, "users/abc/orders")
+	require.NoError(t, err)
+	require.Equal(t, "orders", ref.ID)
+	_, err = collectionRef(client, "users/abc")
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	columnStatsFrameName = "__column_stats"
+
+	// maxDistinctCount caps the distinct values counted per column.
+	maxDistinctCount = 10000
+)
+
+// newColumnStatsFrame returns a frame with one row per field of frame,
+// holding its Go type, its number of null, non-null and distinct values,
+// and, for numeric and time fields, its minimum and maximum values.
+func newColumnStatsFrame(frame *data.Frame) *data.Frame {
+	n := len(frame.Fields)
+	var (
+		names     = make([]string, n)
+		types     = make([]string, n)
+		nulls     = make([]int64, n)
+		nonNulls  = make([]int64, n)
+		distincts = make([]int64, n)
+		mins      = make([]*string, n)
+		maxs      = make([]*string, n)
+	)
+	for i, field := range frame.Fields {
+		names[i] = field.Name
+		types[i] = field.Type().ItemTypeString()
+
+		distinct := map[string]struct{}{}
+		var minValue, maxValue interface{}
+		for row := 0; row < field.Len(); row++ {
+			v, ok := field.ConcreteAt(row)
+			if !ok {
+				nulls[i]++
+				continue
+			}
+			nonNulls[i]++
+			if len(distinct) < maxDistinctCount {
+				distinct[stringifyValue(v)] = struct{}{}
+			}
+			if minValue == nil || lessStatValue(v, minValue) {
+				minValue = v
+			}
+			if maxValue == nil || lessStatValue(maxValue, v) {
+				maxValue = v
+			}
+		}
+		distincts[i] = int64(len(distinct))
+		if field.Type().Numeric() || field.Type().Time() {
+			mins[i] = statValueString(minValue)
+			maxs[i] = statValueString(maxValue)
+		}
+	}
+
+	stats := data.NewFrame(columnStatsFrameName,
+		data.NewField("column_name", nil, names),
+		data.NewField("go_type", nil, types),
+		data.NewField("null_count", nil, nulls),
+		data.NewField("non_null_count", nil, nonNulls),
+		data.NewField("distinct_count", nil, distincts),
+		data.NewField("min_value", nil, mins),
+		data.NewField("max_value", nil, maxs),
+	)
+	stats.RefID = frame.RefID
+	return stats
+}
+
+// lessStatValue reports whether a is less than b, for numeric and time
+// values. Other values are never less.
+func lessStatValue(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Before(tb)
+	}
+	fa, ok := toFloat64(a)
+	if !ok {
+		return false
+	}
+	fb, ok := toFloat64(b)
+	return ok && fa < fb
+}
+
+func statValueString(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	s := stringifyValue(v)
+	return &s
+}
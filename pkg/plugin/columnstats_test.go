@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewColumnStatsFrame(t *testing.T) {
+	t1 := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	a, b := "a", "b"
+	f1, f2, f3 := 2.5, -1.0, 2.5
+	frame := data.NewFrame("response",
+		data.NewField("name", nil, []*string{&a, &b, nil, &a}),
+		data.NewField("score", nil, []*float64{&f1, &f2, &f3, nil}),
+		data.NewField("created", nil, []*time.Time{&t2, nil, &t1, nil}),
+	)
+	frame.RefID = "A"
+
+	stats := newColumnStatsFrame(frame)
+	require.Equal(t, columnStatsFrameName, stats.Name)
+	require.Equal(t, "A", stats.RefID)
+	require.Equal(t, 3, stats.Rows())
+
+	row := func(i int) []interface{} {
+		values := make([]interface{}, len(stats.Fields))
+		for j, f := range stats.Fields {
+			if v, ok := f.ConcreteAt(i); ok {
+				values[j] = v
+			}
+		}
+		return values
+	}
+	require.Equal(t, []interface{}{"name", "*string", int64(1), int64(3), int64(2), nil, nil}, row(0))
+	require.Equal(t, []interface{}{"score", "*float64", int64(1), int64(3), int64(2), "-1", "2.5"}, row(1))
+	require.Equal(t, []interface{}{"created", "*time.Time", int64(2), int64(2), int64(2),
+		"2024-03-15T00:00:00Z", "2024-03-15T01:00:00Z"}, row(2))
+}
+
+func TestShowColumnStatsQuery(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"value"},
+		Records: [][]interface{}{{int64(1)}, {int64(3)}},
+	}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select value from metrics", "showColumnStats": true}`)
+	require.NoError(t, resp.Error)
+	require.Len(t, resp.Frames, 2)
+	require.Equal(t, columnStatsFrameName, resp.Frames[1].Name)
+}
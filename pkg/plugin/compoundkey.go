@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const defaultCompoundKeyName = "compound_key"
+
+// addCompoundKey appends a string field named name whose values join the
+// URL-escaped values of fields with "_". Rows where any of the fields is nil
+// get a nil key.
+func addCompoundKey(frame *data.Frame, fields []string, name string) error {
+	if name == "" {
+		name = defaultCompoundKeyName
+	}
+	components := make([]*data.Field, len(fields))
+	for i, fieldName := range fields {
+		field, _ := frame.FieldByName(fieldName)
+		if field == nil {
+			return fmt.Errorf("compound key field %q not found", fieldName)
+		}
+		components[i] = field
+	}
+
+	keys := make([]*string, frame.Rows())
+	parts := make([]string, len(components))
+	for row := range keys {
+		complete := true
+		for i, field := range components {
+			v, ok := field.ConcreteAt(row)
+			if !ok {
+				complete = false
+				break
+			}
+			parts[i] = url.PathEscape(stringifyValue(v))
+		}
+		if complete {
+			key := strings.Join(parts, "_")
+			keys[row] = &key
+		}
+	}
+	frame.Fields = append(frame.Fields, data.NewField(name, nil, keys))
+	return nil
+}
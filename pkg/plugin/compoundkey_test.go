@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddCompoundKey(t *testing.T) {
+	regions := []string{"us-east-1", "eu west"}
+	hosts := []string{"web01", "web/02"}
+	frame := data.NewFrame("response",
+		data.NewField("region", nil, []*string{&regions[0], &regions[1], &regions[0]}),
+		data.NewField("host", nil, []*string{&hosts[0], &hosts[1], nil}),
+	)
+
+	require.NoError(t, addCompoundKey(frame, []string{"region", "host"}, "server"))
+	key := frame.Fields[2]
+	require.Equal(t, "server", key.Name)
+	require.Equal(t, "us-east-1_web01", *key.At(0).(*string))
+	require.Equal(t, "eu%20west_web%2F02", *key.At(1).(*string))
+	require.Nil(t, key.At(2))
+
+	require.NoError(t, addCompoundKey(frame, []string{"region"}, ""))
+	require.Equal(t, defaultCompoundKeyName, frame.Fields[3].Name)
+
+	require.Error(t, addCompoundKey(frame, []string{"missing"}, "key"))
+}
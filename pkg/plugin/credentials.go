@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	vkit "cloud.google.com/go/firestore/apiv1"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+// findDefaultCredentials looks up the Application Default Credentials. It is
+// replaced in tests.
+var findDefaultCredentials = google.FindDefaultCredentials
+
+// defaultCredentialsOption returns the client option authenticating with the
+// Application Default Credentials of the environment, such as the attached
+// service account on GCE, Cloud Run or GKE workload identity.
+func defaultCredentialsOption(ctx context.Context) (option.ClientOption, error) {
+	creds, err := findDefaultCredentials(ctx, vkit.DefaultAuthScopes()...)
+	if err != nil {
+		return nil, fmt.Errorf("ADC not available: no Application Default Credentials found in this environment: %v", err)
+	}
+	return option.WithCredentials(creds), nil
+}
+
+// externalAccountType is the credential type of Workload Identity Federation
+// configuration files.
+const externalAccountType = "external_account"
+
+// workloadIdentityCredentials loads the Workload Identity Federation
+// credential configuration file at path. Tokens are exchanged lazily, when
+// the credentials are first used.
+func workloadIdentityCredentials(ctx context.Context, path string) (*google.Credentials, error) {
+	config, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("WorkloadIdentityConfigFile: %v", err)
+	}
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(config, &header); err != nil {
+		return nil, fmt.Errorf("WorkloadIdentityConfigFile: malformed JSON: %v", err)
+	}
+	if header.Type != externalAccountType {
+		return nil, fmt.Errorf("WorkloadIdentityConfigFile: expected credential type %q, got %q", externalAccountType, header.Type)
+	}
+	creds, err := google.CredentialsFromJSONWithParams(ctx, config, google.CredentialsParams{Scopes: vkit.DefaultAuthScopes()})
+	if err != nil {
+		return nil, fmt.Errorf("WorkloadIdentityConfigFile: %v", err)
+	}
+	return creds, nil
+}
+
+// checkWorkloadIdentity exchanges a token with the Workload Identity
+// Federation configuration file at path, reporting why it failed.
+func checkWorkloadIdentity(ctx context.Context, path string) error {
+	creds, err := workloadIdentityCredentials(ctx, path)
+	if err != nil {
+		return err
+	}
+	if _, err := creds.TokenSource.Token(); err != nil {
+		return fmt.Errorf("workload identity token exchange failed: %v", err)
+	}
+	return nil
+}
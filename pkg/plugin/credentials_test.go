@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+func TestDefaultCredentials(t *testing.T) {
+	t.Setenv(emulatorHostEnvVar, "")
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"ProjectId": "test", "UseApplicationDefaultCredentials": true}`),
+	}}
+	defer func(find func(context.Context, ...string) (*google.Credentials, error)) {
+		findDefaultCredentials = find
+	}(findDefaultCredentials)
+
+	findDefaultCredentials = func(context.Context, ...string) (*google.Credentials, error) {
+		return nil, errors.New("could not find default credentials")
+	}
+	_, err := newFirestoreClient(context.Background(), pCtx)
+	require.ErrorContains(t, err, "ADC not available")
+
+	var found bool
+	findDefaultCredentials = func(context.Context, ...string) (*google.Credentials, error) {
+		found = true
+		return &google.Credentials{ProjectID: "test", TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})}, nil
+	}
+	client, err := newFirestoreClient(context.Background(), pCtx)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.NoError(t, client.Close())
+}
+
+func TestCheckWorkloadIdentity(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	ctx := context.Background()
+
+	require.ErrorContains(t, checkWorkloadIdentity(ctx, filepath.Join(dir, "missing.json")), "WorkloadIdentityConfigFile")
+	require.ErrorContains(t, checkWorkloadIdentity(ctx, write("malformed.json", "{")), "malformed JSON")
+	require.ErrorContains(t, checkWorkloadIdentity(ctx, write("key.json", `{"type": "service_account"}`)), `expected credential type "external_account"`)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+	}))
+	defer tokenServer.Close()
+	subjectToken := write("token", "subject-token")
+	config := write("wif.json", fmt.Sprintf(`{
+		"type": "external_account",
+		"audience": "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider",
+		"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"token_url": %q,
+		"credential_source": {"file": %q}
+	}`, tokenServer.URL, subjectToken))
+	require.ErrorContains(t, checkWorkloadIdentity(ctx, config), "workload identity token exchange failed")
+}
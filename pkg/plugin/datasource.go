@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
-	
+	"sync"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	vkit "cloud.google.com/go/firestore/apiv1"
@@ -16,14 +20,16 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
 	"golang.org/x/oauth2/google"
-	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
 var (
 	_ backend.QueryDataHandler      = (*Datasource)(nil)
 	_ backend.CheckHealthHandler    = (*Datasource)(nil)
+	_ backend.CallResourceHandler   = (*Datasource)(nil)
+	_ backend.StreamHandler         = (*Datasource)(nil)
 	_ instancemgmt.InstanceDisposer = (*Datasource)(nil)
 )
 
@@ -31,10 +37,82 @@ func NewDatasource(_ backend.DataSourceInstanceSettings) (instancemgmt.Instance,
 	return &Datasource{}, nil
 }
 
-type Datasource struct{}
+type Datasource struct {
+	// fieldDescriptionsCache holds fieldDescriptionsEntry values by
+	// datasourceCacheKey of the collection name.
+	fieldDescriptionsCache sync.Map
+	// incrementalStates holds *incrementalState values by RefID.
+	incrementalStates sync.Map
+	schemas           dynamicSchema
+	clients           clientCache
+	pollers           pollers
+	// loadedRefIDs holds the RefIDs queried since the query cache was last
+	// purged, for ForceReloadOnDashboardLoad.
+	loadedRefIDs sync.Map
+	inflight     inflightQueries
+	// externalSchemaCache holds externalSchemaEntry values by schema URL.
+	externalSchemaCache sync.Map
+	// etags holds the ETag of the last frame returned by datasourceCacheKey
+	// of the query JSON and RefID.
+	etags sync.Map
+	// queryCache holds *queryCacheEntry values by query signature.
+	queryCache sync.Map
+
+	resourceHandlerOnce sync.Once
+	resourceHandler     backend.CallResourceHandler
+
+	// newExecutor overrides how FireQL executors are created. It is nil
+	// outside of tests.
+	newExecutor func(projectID string, options ...fireql.Option) (queryExecutor, error)
+	// ping overrides how CheckHealth reaches Firestore and counts its root
+	// collections. It is nil outside of tests.
+	ping func(ctx context.Context, pCtx backend.PluginContext) (int, error)
+	// listCollections overrides how collections are listed. It is nil
+	// outside of tests.
+	listCollections func(ctx context.Context, pCtx backend.PluginContext, parent string) ([]string, error)
+	// countBefore overrides how documents are counted for capacity planning.
+	// It is nil outside of tests.
+	countBefore func(ctx context.Context, pCtx backend.PluginContext, collection, timeField string, before time.Time) (int64, error)
+}
+
+// queryExecutor runs a FireQL query. It is implemented by *fireql.FireQL.
+type queryExecutor interface {
+	Execute(query string) (*util.QueryResult, error)
+}
+
+// executor returns the executor running the FireQL queries of the
+// datasource. Statements are run with the cached Firestore client when
+// possible, so that queries do not each open a new connection. The client is
+// looked up for every statement, as the executor may be kept by a poller
+// after the cached client was closed.
+func (d *Datasource) executor(pCtx backend.PluginContext, settings FirestoreSettings, options ...fireql.Option) (queryExecutor, error) {
+	if d.newExecutor != nil {
+		return d.newExecutor(settings.ProjectId, options...)
+	}
+	// Report invalid settings now rather than on the first statement
+	_, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	executor := &clientExecutor{
+		client:       func() (*firestore.Client, func(), error) { return d.firestoreClient(pCtx) },
+		defaultLimit: queryLimit(settings),
+	}
+	if fireqlCompatible(settings) {
+		fQuery, err := fireql.New(settings.ProjectId, options...)
+		if err != nil {
+			return nil, err
+		}
+		executor.fallback = fQuery
+	}
+	return executor, nil
+}
 
 func (d *Datasource) Dispose() {
 	// Clean up datasource instance resources.
+	d.pollers.stopAll()
+	d.clients.closeAll()
 }
 
 func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
@@ -42,21 +120,408 @@ func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataReques
 
 	response := backend.NewQueryDataResponse()
 
-	for _, q := range req.Queries {
-		res := d.query(ctx, req.PluginContext, q)
-		response.Responses[q.RefID] = res
+	// Invalid settings are reported by each query
+	var settings FirestoreSettings
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		_ = json.Unmarshal(req.PluginContext.DataSourceInstanceSettings.JSONData, &settings)
 	}
 
+	// Firestore clients are cached per datasource, so concurrent queries
+	// share their connection.
+	response.Responses = runParallel(req.Queries, settings.MaxParallelQueries, func(q backend.DataQuery) backend.DataResponse {
+		run := func() backend.DataResponse {
+			if settings.CacheTTLSeconds > 0 {
+				return d.cachedQuery(ctx, req.PluginContext, q, time.Duration(settings.CacheTTLSeconds)*time.Second)
+			}
+			return d.query(ctx, req.PluginContext, q)
+		}
+
+		if settings.RequestDedup {
+			return d.inflight.do(ctx, querySignature(q), run)
+		}
+		return run()
+	})
+
 	return response, nil
 }
 
 type FirestoreQuery struct {
 	Query string
+	// Transformers post-process the response frame, in order.
+	Transformers []FirestoreTransformer
+	// SortLocale collates strings in sort transformers according to the
+	// given language, e.g. "fr". Strings are compared byte-wise when empty.
+	SortLocale string
+	// GeoFilter keeps only documents within a radius of a center point.
+	GeoFilter *GeoFilter
+	// Explain returns the Firestore query plan as an additional frame.
+	// Unless ExplainPlanOnly is set, the frame also holds execution statistics,
+	// collected while running the query itself when it is a plain SELECT.
+	Explain         bool
+	ExplainPlanOnly bool
+	// LoadFieldDescriptions attaches the descriptions stored in the
+	// _meta/<collection> document to the matching fields.
+	LoadFieldDescriptions bool
+	// AutoPagination runs the query directly against Firestore and fetches
+	// every page of results, up to MaxRows or the MaxDocuments setting,
+	// whichever is lower.
+	AutoPagination bool
+	MaxRows        int
+	// NaNHandling controls how NaN and Inf float values are returned:
+	// "nil", "zero" or "string". They are returned as is when empty.
+	NaNHandling string
+	// AutoParseTimestamps converts the string columns whose values all parse
+	// with one of TimestampFormats (RFC 3339, "2006-01-02 15:04:05" and
+	// "2006-01-02" by default) into time columns.
+	AutoParseTimestamps bool
+	TimestampFormats    []string
+	// ArrayHandling controls how array values are returned: "json" (the
+	// default) encodes them as JSON, "length" returns their length and
+	// "first" their first element.
+	ArrayHandling string
+	// GeoPointHandling controls how GeoPoint values are returned: "split"
+	// (the default) into <field>.latitude and <field>.longitude fields, "wkt"
+	// as POINT(lon lat) or "json" as {"lat":..,"lng":..}.
+	GeoPointHandling string
+	// DocumentReferenceHandling controls how document references are
+	// returned: "path" (the default) as their full resource path, "id" as
+	// their document ID or "url" as a link to the Firebase console.
+	DocumentReferenceHandling string
+	// BlobEncoding controls how Blob values are returned: "base64" (the
+	// default), "base64url" or "hex". Blobs larger than MaxBlobBytes
+	// (default 1024) are truncated.
+	BlobEncoding string
+	MaxBlobBytes int
+	// TemplateVars holds the values of the ${name} template variables of a
+	// sub-collection path in the FROM clause, e.g. users/${userId}/orders.
+	TemplateVars map[string]string
+	// MultiValueVars holds the values of the multi-value variables used as
+	// IN (${name}) or NOT IN (${name}) lists in the WHERE clause.
+	MultiValueVars map[string][]string
+	// ParameterizedQuery, when set, replaces Query. Its @name placeholders
+	// are bound to the matching Parameters as escaped literals.
+	ParameterizedQuery string
+	Parameters         map[string]interface{}
+	// IncrementalRefresh runs the query directly against Firestore and, after
+	// the first run, only fetches documents whose IncrementalTimeField is after
+	// the latest value seen so far, merging them into the previous results.
+	IncrementalRefresh   bool
+	IncrementalTimeField string
+	// TemporalDecayField adds a __weight column decaying exponentially with
+	// the age of the field's time relative to the end of the time range,
+	// halving every DecayHalfLifeMinutes.
+	TemporalDecayField   string
+	DecayHalfLifeMinutes float64
+	// StructuredQuery is run directly against Firestore when Query is empty.
+	// It is usually built with FirestoreQueryBuilder.
+	StructuredQuery *StructuredQuery
+	// MetadataOnly fetches a single document and returns a frame without rows
+	// whose fields carry the names and inferred types of the query columns.
+	MetadataOnly bool
+	// FrameName names the response frame. It defaults to the collection of
+	// the FROM clause, then to the RefID of the query.
+	FrameName string
+	// FrameMetadata is merged into the custom metadata of the response frame.
+	// Keys must not start with "__".
+	FrameMetadata map[string]string
+	// AutoDetectLongFormat marks frames with one time, one string and one
+	// numeric field as long time series. It defaults to true.
+	AutoDetectLongFormat *bool
+	// TemporalSamplingPoints down-samples the response to at most this many
+	// rows, keeping the latest row of each equal-width bin of
+	// TemporalSamplingField, or of the first time field when empty.
+	TemporalSamplingPoints int
+	TemporalSamplingField  string
+	// CompoundKeyFields adds a field named CompoundKeyName (default
+	// "compound_key") joining the values of the listed fields with "_".
+	CompoundKeyFields []string
+	CompoundKeyName   string
+	// SubQueries are run concurrently with Query and their results appended
+	// to its results, e.g. to emulate OR conditions. A failing query is
+	// reported as a warning unless AbortOnFirstError is set, in which case
+	// only the error is returned.
+	SubQueries        []string
+	AbortOnFirstError bool
+	// EnrichmentURL is called with a "key" query parameter for each distinct
+	// value of EnrichmentKeyField, using EnrichmentMethod (GET or POST,
+	// default GET). The fields of the JSON objects returned are added to the
+	// response. It must be under one of the EnrichmentAllowedURLs settings.
+	EnrichmentURL      string
+	EnrichmentKeyField string
+	EnrichmentMethod   string
+	// BackgroundPolling re-executes the query every PollingIntervalSecs
+	// (default 30) after its first successful run, and serves the latest
+	// polled result to later requests.
+	BackgroundPolling   bool
+	PollingIntervalSecs int
+	// HistogramField returns the frequency distribution of the numeric field
+	// over HistogramBuckets (default 10) equal-width bins instead of the rows.
+	HistogramField   string
+	HistogramBuckets int
+	// ForceReloadOnDashboardLoad executes a BackgroundPolling query instead
+	// of serving its polled result on the first call of the RefID since the
+	// query cache was last purged, or when the query type contains "load".
+	ForceReloadOnDashboardLoad bool
+	// WildcardCollection runs the query against every root collection whose
+	// name matches the glob pattern, e.g. "events_*", adding a
+	// __source_collection column.
+	WildcardCollection string
+	// SequenceField sorts the rows by the integer field and adds a
+	// __sequence_gap column holding the difference with the previous value
+	// minus ExpectedSequenceGap (default 1).
+	SequenceField       string
+	ExpectedSequenceGap int
+	// SentinelValues maps column names to the values standing for a missing
+	// value in them, e.g. "-1" or "N/A", which are returned as nil.
+	SentinelValues map[string][]string
+	// CollapseByField collapses the rows sharing the same value of the field
+	// into one, aggregating numeric fields with CollapseAggregation ("sum",
+	// "avg", "min", "max" or "count") and joining the values of the others.
+	// With the default "concat" aggregation every field is joined.
+	CollapseByField     string
+	CollapseAggregation string
+	// GroupBy returns one row per distinct value of the field, holding the
+	// Aggregations of its documents. Grouping is done client-side, for up to
+	// 1000 groups.
+	GroupBy      string
+	Aggregations []AggregationSpec
+	// IncludeFields, when set, keeps only the listed fields of the response
+	// frame, and ExcludeFields removes the listed ones. Fields injected by the
+	// plugin, such as __document_id, are kept unless excluded.
+	IncludeFields []string
+	ExcludeFields []string
+	// RenameFields maps field names to the display name of their field.
+	RenameFields map[string]string
+	// ShowColumnStats returns a second __column_stats frame describing the
+	// values of every field of the response frame.
+	ShowColumnStats bool
+	// LogMode returns a log frame for Grafana Explore, whose time, body and
+	// optional level fields are read from TimeField (the first time field by
+	// default), MessageField ("message" by default) and LevelField.
+	LogMode      bool
+	TimeField    string
+	MessageField string
+	LevelField   string
+	// TimeoutSeconds bounds the execution of the query. It defaults to the
+	// DefaultTimeoutSeconds of the datasource, or 30 seconds unless
+	// DeadlineExtensionSecs is set.
+	TimeoutSeconds int
+	// FlattenNestedFields expands map fields into one field per nested
+	// field, e.g. address.city, down to MaxFlattenDepth levels (default 3).
+	// NestedFieldSeparator joins the keys of the field names (default ".").
+	FlattenNestedFields  bool
+	MaxFlattenDepth      int
+	NestedFieldSeparator string
+	// AddRowNumber prepends a __row_number field numbering the rows from 1,
+	// in the order they are returned.
+	AddRowNumber bool
+	// DatabaseName overrides the database of the datasource for the query.
+	DatabaseName string
+	// IncludeDocumentMetadata adds the __create_time and __update_time
+	// fields of each document. The query must select __name__.
+	IncludeDocumentMetadata bool
+	// StreamQuery configures the live stream of Firestore document changes
+	// subscribed to with the query.
+	StreamQuery *StreamQuery
+	// StaleDataWarningMinutes adds a warning notice when the latest value of
+	// StaleDataTimeField is older than this many minutes.
+	StaleDataWarningMinutes int
+	StaleDataTimeField      string
+	// MinTimeRangeSecs rejects dashboard time ranges shorter than this many
+	// seconds. MaxTimeRangeDays shortens longer time ranges to their last
+	// MaxTimeRangeDays days and adds a warning notice.
+	MinTimeRangeSecs int
+	MaxTimeRangeDays int
 }
 
 type FirestoreSettings struct {
 	ProjectId    string
 	DatabaseName string
+	// EmulatorHost connects to the Firestore emulator at this address, e.g.
+	// localhost:8080, without credentials or TLS. It takes precedence over
+	// FIRESTORE_EMULATOR_HOST.
+	EmulatorHost string
+	// RetryOnQuotaExceeded retries a query once, after QuotaRetryDelayMs
+	// (default 1000), when Firestore reports RESOURCE_EXHAUSTED.
+	RetryOnQuotaExceeded bool
+	QuotaRetryDelayMs    int
+	// RetryMaxAttempts is the number of attempts of a query failing with
+	// UNAVAILABLE or RESOURCE_EXHAUSTED (default 3), backing off from
+	// RetryInitialBackoffMs (default 100). 1 disables the retries.
+	RetryMaxAttempts      int
+	RetryInitialBackoffMs int
+	// MaxParallelQueries caps the queries of a request run concurrently
+	// (default 5).
+	MaxParallelQueries int
+	// MaxCachedClients caps the number of Firestore clients kept open per
+	// datasource instance (default 10).
+	MaxCachedClients int
+	// TLSCACertPEM is a base64-encoded PEM bundle of CA certificates trusted
+	// for Firestore connections, e.g. behind a TLS-terminating proxy.
+	// TLSSkipVerify disables certificate verification and is strongly discouraged.
+	TLSCACertPEM  string
+	TLSSkipVerify bool
+	// ServiceAccounts spreads Firestore usage across several service accounts,
+	// picking one per query at random according to the weights. When empty,
+	// the serviceAccount secure setting is used.
+	ServiceAccounts []WeightedServiceAccount
+	// HealthCheckRetries is the number of attempts made by the health check
+	// before reporting a failure (default 1), HealthCheckRetryDelayMs the
+	// delay between attempts (default 500).
+	HealthCheckRetries      int
+	HealthCheckRetryDelayMs int
+	// AlertOnSchemaDrift adds a warning to the response when the type of a
+	// field differs from the one observed by the previous query of the
+	// same collection.
+	AlertOnSchemaDrift bool
+	// AllowedProjectIDs restricts the projects the datasource may query,
+	// including the one taken from the environment. Empty allows all.
+	AllowedProjectIDs []string
+	// AllowedCollections restricts the collections live queries may stream.
+	// Empty allows all.
+	AllowedCollections []string
+	// DeadlineExtensionSecs extends the deadline Grafana sets on query
+	// requests, up to 60 seconds.
+	DeadlineExtensionSecs int
+	// UseProtobuf checks that the response frames encode to the Arrow format
+	// sent to Grafana in the plugin's protobuf responses, failing only the
+	// query of a frame that cannot, and logs their encoded sizes.
+	UseProtobuf bool
+	// RequestDedup makes a query identical to one already running, with the
+	// same RefID and time range, wait for and share its response.
+	RequestDedup bool
+	// EnrichmentAllowedURLs are the URLs the EnrichmentURL of queries must be
+	// under, e.g. https://api.example.com/customers. Enrichment is disabled
+	// when empty.
+	EnrichmentAllowedURLs []string
+	// ExternalSchemaURL serves the GET /schema resource from an external
+	// endpoint, called with the collection query parameter, instead of
+	// inferring the schema from Firestore documents.
+	ExternalSchemaURL string
+	// WriteEnabled must be set for settings and endpoints that make the
+	// datasource write to Firestore, such as CreateHealthCollection and
+	// POST /clone.
+	WriteEnabled bool
+	// CreateHealthCollection makes the health check create the
+	// _grafana_health_check_ sentinel document when missing. It requires
+	// WriteEnabled.
+	CreateHealthCollection bool
+	// HealthCheckCollection makes the health check read a document of this
+	// collection instead of listing the root collections, which requires
+	// broader permissions.
+	HealthCheckCollection string
+	// ETagSupport tags response frames with an "etag" hash of their data and
+	// marks them "unchanged" when identical to the previous frame of their
+	// RefID, letting the frontend skip re-rendering.
+	ETagSupport bool
+	// SanitizeHTMLInStrings HTML-escapes string values, protecting panels
+	// that render values as HTML from user-generated markup.
+	SanitizeHTMLInStrings bool
+	// DefaultQuery is run for queries without a Query or StructuredQuery.
+	DefaultQuery string
+	// LegacyStringMode returns every value as a string, as versions of the
+	// plugin before typed columns did. It eases migrating dashboards that
+	// expect string columns: enable it, then update the dashboards and
+	// disable it again.
+	LegacyStringMode bool
+	// UseApplicationDefaultCredentials authenticates with the Application
+	// Default Credentials of the environment instead of a service account
+	// JSON key. Without a service account and this setting, the Firestore
+	// client falls back to them implicitly.
+	UseApplicationDefaultCredentials bool
+	// WorkloadIdentityConfigFile is the path of a Workload Identity
+	// Federation credential configuration file, used instead of a service
+	// account JSON key.
+	WorkloadIdentityConfigFile string
+	// CacheTTLSeconds serves the responses of identical queries over the
+	// same time range from a cache for this many seconds, saving document
+	// reads. 0 disables the cache.
+	CacheTTLSeconds int
+	// DefaultTimeoutSeconds bounds the execution of queries without a
+	// TimeoutSeconds (default 30).
+	DefaultTimeoutSeconds int
+	// DefaultLimit is the limit of queries without a LIMIT clause (default
+	// 1000), and MaxDocuments caps the documents returned by a query
+	// (default 10000). Firestore bills each document read.
+	DefaultLimit int
+	MaxDocuments int
+}
+
+// projectIDEnvVars are the standard GCP environment variables consulted, in
+// order, when no project ID is configured on the datasource.
+var projectIDEnvVars = []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT", "GCLOUD_PROJECT_ID"}
+
+func projectIDFromEnvironment() string {
+	for _, name := range projectIDEnvVars {
+		if projectID := os.Getenv(name); projectID != "" {
+			log.DefaultLogger.Info("Using project ID from environment", "variable", name)
+			return projectID
+		}
+	}
+	return ""
+}
+
+// checkProjectAllowed returns an error if the project is not in the
+// AllowedProjectIDs of the settings.
+func checkProjectAllowed(settings FirestoreSettings, projectID string) error {
+	if len(settings.AllowedProjectIDs) == 0 || containsString(settings.AllowedProjectIDs, projectID) {
+		return nil
+	}
+	return fmt.Errorf("project %q is not allowed for this datasource", projectID)
+}
+
+var collectionNameRegex = regexp.MustCompile("(?i)\\bfrom\\s+`?([^\\s`]+)`?")
+
+// extractCollectionName returns the collection named in the FROM clause of a
+// FireQL query, or an empty string if none is found.
+func extractCollectionName(query string) string {
+	match := collectionNameRegex.FindStringSubmatch(query)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// responseFrameName returns the name of the response frame of a query:
+// name when set, else the collection queried, else refID.
+func responseFrameName(name, query, refID string) string {
+	if name != "" {
+		return name
+	}
+	if collection := strings.Trim(extractCollectionName(query), "[]"); collection != "" {
+		return collection
+	}
+	return refID
+}
+
+// prepareQuery expands the macros and variables of the query string of qm
+// over the time range and rewrites it into a statement FireQL runs.
+func prepareQuery(query string, qm FirestoreQuery, queryType string, tr backend.TimeRange) (string, error) {
+	query = expandMacros(query, tr)
+	query, err := quoteSubcollectionPath(query, qm.TemplateVars)
+	if err != nil {
+		return "", err
+	}
+	query = rewriteCollectionGroups(query, queryType)
+	query, err = expandMultiValueVars(query, qm.MultiValueVars)
+	if err != nil {
+		return "", err
+	}
+	if err := checkInLists(query); err != nil {
+		return "", err
+	}
+	return rewriteArrayOperators(query), nil
+}
+
+// datasourceCacheKey returns the key caching values of parts for the
+// datasource instance, project and database of a query, so that they are
+// not shared across datasources or per-query DatabaseName overrides.
+func datasourceCacheKey(pCtx backend.PluginContext, settings FirestoreSettings, parts ...string) string {
+	uid := ""
+	if pCtx.DataSourceInstanceSettings != nil {
+		uid = pCtx.DataSourceInstanceSettings.UID
+	}
+	return strings.Join(append([]string{uid, settings.ProjectId, settings.DatabaseName}, parts...), "\x00")
 }
 
 func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (response backend.DataResponse) {
@@ -70,7 +535,6 @@ func (d *Datasource) query(ctx context.Context, pCtx backend.PluginContext, quer
 	return response
 }
 
-
 ////////////////////////////////////
 
 func (d *Datasource) queryInternal(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) backend.DataResponse {
@@ -84,6 +548,25 @@ func (d *Datasource) queryInternal(ctx context.Context, pCtx backend.PluginConte
 	}
 	log.DefaultLogger.Debug("FirestoreQuery: ", qm)
 
+	timeRangeNotice, err := limitTimeRange(&query.TimeRange, qm.MinTimeRangeSecs, qm.MaxTimeRangeDays)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	if qm.ParameterizedQuery != "" {
+		qm.Query, err = bindParameters(qm.ParameterizedQuery, qm.Parameters)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, "parameters: "+err.Error())
+		}
+	}
+
+	if qm.DatabaseName != "" {
+		pCtx, err = withDatabaseName(pCtx, qm.DatabaseName)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+	}
+
 	var settings FirestoreSettings
 	err = json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings)
 	if err != nil {
@@ -91,104 +574,553 @@ func (d *Datasource) queryInternal(ctx context.Context, pCtx backend.PluginConte
 		return backend.ErrDataResponse(backend.StatusBadRequest, "ProjectID: "+err.Error())
 	}
 
+	if len(settings.ProjectId) == 0 {
+		settings.ProjectId = projectIDFromEnvironment()
+	}
 	if len(settings.ProjectId) == 0 {
 		return backend.ErrDataResponse(backend.StatusBadRequest, "ProjectID is required")
 	}
+	if err := checkProjectAllowed(settings, settings.ProjectId); err != nil {
+		return backend.ErrDataResponse(backend.StatusUnauthorized, err.Error())
+	}
+
+	if len(qm.Query) == 0 && qm.StructuredQuery == nil && settings.DefaultQuery != "" {
+		log.DefaultLogger.Debug("Using the default query", "refId", query.RefID)
+		qm.Query = settings.DefaultQuery
+	}
+	rawQuery := qm.Query
+	qm.Query, err = prepareQuery(rawQuery, qm, query.QueryType, query.TimeRange)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := queryContext(ctx, settings)
+	defer cancel()
+	timeout := queryTimeout(qm, settings)
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	} else if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline).Round(time.Second)
+	}
 
 	var options []fireql.Option
-	if pCtx.DataSourceInstanceSettings.DecryptedSecureJSONData["serviceAccount"] != "" {
-		options = append(options, fireql.OptionServiceAccount(pCtx.DataSourceInstanceSettings.DecryptedSecureJSONData["serviceAccount"]))
+	if serviceAccount := selectServiceAccount(settings, pCtx); serviceAccount != "" {
+		options = append(options, fireql.OptionServiceAccount(serviceAccount))
 	}
 
 	if settings.DatabaseName != "" {
 		options = append(options, fireql.OptionDatabaseName(settings.DatabaseName))
 	}
+	options = append(options, fireql.OptionDefaultLimit(queryLimit(settings)))
 
-	fQuery, err := fireql.New(settings.ProjectId, options...)
+	fQuery, err := d.executor(pCtx, settings, options...)
 	if err != nil {
-		return backend.ErrDataResponse(backend.StatusBadRequest, "fireql.NewFireQL: "+err.Error())
+		return firestoreErrorResponse("fireql.NewFireQL", err)
 	}
 
 	log.DefaultLogger.Info("Created fireql.NewFireQLWithServiceAccountJSON")
 
-	if len(qm.Query) > 0 {
+	if query.QueryType == annotationQueryType {
+		var aq AnnotationQuery
+		if err := json.Unmarshal(query.JSON, &aq); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, "json unmarshal: "+err.Error())
+		}
+		frame, err := d.annotationQuery(ctx, pCtx, aq, query.TimeRange)
+		logAuditEvent(ctx, pCtx, auditQuery(aq), aq.Collection, frameRows(frame), err)
+		if err != nil {
+			return firestoreErrorResponse("", err)
+		}
+		response.Frames = append(response.Frames, frame)
+		return response
+	}
+
+	if query.QueryType == variableQueryType {
+		var vq VariableQuery
+		if err := json.Unmarshal(query.JSON, &vq); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, "json unmarshal: "+err.Error())
+		}
+		frame, err := d.variableQuery(ctx, pCtx, fQuery, vq, settings)
+		logAuditEvent(ctx, pCtx, auditQuery(vq), vq.Collection, frameRows(frame), err)
+		if err != nil {
+			return firestoreErrorResponse("", err)
+		}
+		response.Frames = append(response.Frames, frame)
+		return response
+	}
+
+	if err := validateFrameMetadata(qm.FrameMetadata); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	if qm.MetadataOnly {
+		if qm.StructuredQuery != nil {
+			limited := *qm.StructuredQuery
+			limited.Limit = 1
+			qm.StructuredQuery = &limited
+		}
+		if len(qm.Query) > 0 {
+			qm.Query, err = limitQuery(qm.Query, 1)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		}
+	}
+
+	if len(qm.Query) > 0 || qm.StructuredQuery != nil {
 		log.DefaultLogger.Info("Executing query", qm.Query)
-		result, err := fQuery.Execute(qm.Query)
+		started := time.Now()
+		var result *util.QueryResult
+		var subQueryErrs []error
+		// explainFrame is set when the query itself collected the explain metrics
+		var explainFrame *data.Frame
+		explainStmt, explainErr := parseSelectStatement(qm.Query)
+		switch {
+		case len(qm.Query) == 0:
+			result, err = d.runStructuredQuery(ctx, pCtx, settings, qm.StructuredQuery)
+		case qm.IncrementalRefresh:
+			result, err = d.incrementalQuery(ctx, pCtx, settings, query.RefID, qm)
+		case qm.AutoPagination:
+			result, err = d.paginateQuery(ctx, pCtx, settings, qm.Query, qm.MaxRows)
+		case qm.WildcardCollection != "":
+			result, err = d.wildcardQuery(ctx, pCtx, fQuery, qm, settings)
+		case len(qm.SubQueries) > 0:
+			queries := append([]string{qm.Query}, qm.SubQueries...)
+			result, subQueryErrs, err = runUnion(ctx, queries, func(ctx context.Context, query string) (*util.QueryResult, error) {
+				return executeQuery(ctx, fQuery, query, settings)
+			}, qm.AbortOnFirstError)
+		case qm.BackgroundPolling:
+			prepare := func(tr backend.TimeRange) (string, error) {
+				prepared, err := prepareQuery(rawQuery, qm, query.QueryType, tr)
+				if err != nil || !qm.MetadataOnly {
+					return prepared, err
+				}
+				return limitQuery(prepared, 1)
+			}
+			result, err = d.pollQuery(ctx, pCtx, fQuery, qm, settings, d.forceReload(query, qm), query.TimeRange, prepare)
+		case qm.Explain && !qm.ExplainPlanOnly && explainErr == nil:
+			result, explainFrame, err = d.explainAnalyzeQuery(ctx, pCtx, explainStmt, settings)
+		default:
+			result, err = executeQuery(ctx, fQuery, qm.Query, settings)
+		}
+		rows := 0
+		if result != nil {
+			rows = len(result.Records)
+		}
+		if len(qm.Query) == 0 {
+			logAuditEvent(ctx, pCtx, auditQuery(qm.StructuredQuery), qm.StructuredQuery.Collection, rows, err)
+		} else {
+			logAuditEvent(ctx, pCtx, qm.Query, extractCollectionName(qm.Query), rows, err)
+		}
+		if isTimeout(err) {
+			return backend.ErrDataResponse(backend.StatusTimeout, fmt.Sprintf("query timed out after %d seconds", int(timeout.Seconds())))
+		}
+		if isCancelled(err) {
+			return backend.ErrDataResponse(statusCancelled, "query cancelled")
+		}
+		var quotaErr *quotaExceededError
+		if errors.As(err, &quotaErr) {
+			return backend.ErrDataResponse(backend.StatusTooManyRequests, "fireql.Execute: "+err.Error())
+		}
+		if err != nil {
+			if res, ok := missingIndexResponse(err); ok {
+				res.Frames[0].RefID = query.RefID
+				return res
+			}
+			return firestoreErrorResponse("fireql.Execute", err)
+		}
+		truncatedNotice := truncateRecords(result, settings)
+
+		if qm.MetadataOnly {
+			frame, err := newSchemaFrame(result)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusInternal, err.Error())
+			}
+			response.Frames = append(response.Frames, frame)
+			return response
+		}
+
+		if qm.FlattenNestedFields {
+			flattenNestedFields(result, qm.MaxFlattenDepth, qm.NestedFieldSeparator)
+		}
+		if err := handleArrays(result, qm.ArrayHandling); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+		if err := handleNonFiniteFloats(result, qm.NaNHandling); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+		replaceSentinelValues(result, qm.SentinelValues)
+		if qm.AutoParseTimestamps {
+			parseTimestampColumns(result, qm.TimestampFormats)
+		}
+
+		var distances []*float64
+		if qm.GeoFilter != nil {
+			result, distances, err = filterByDistance(result, qm.GeoFilter)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		}
+
+		if err := encodeBlobs(result, qm.BlobEncoding, qm.MaxBlobBytes); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+		if err := handleGeoPoints(result, qm.GeoPointHandling); err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+		urlColumns, err := handleDocumentRefs(result, qm.DocumentReferenceHandling)
 		if err != nil {
-			return backend.ErrDataResponse(backend.StatusBadRequest, "fireql.Execute: "+err.Error())
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+
+		if qm.GroupBy != "" {
+			if err := groupRecords(result, qm.GroupBy, qm.Aggregations); err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+			// Groups have no distance of their own
+			distances = nil
+		}
+
+		if qm.HistogramField != "" {
+			frame, err := newHistogramFrame(result, qm.HistogramField, qm.HistogramBuckets)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+			setCustomMeta(frame, "executionTimeMs", time.Since(started).Milliseconds())
+			applyFrameMetadata(frame, qm.FrameMetadata)
+			response.Frames = append(response.Frames, frame)
+			return response
+		}
+
+		var sources []*string
+		if qm.WildcardCollection != "" {
+			sources = extractSourceCollections(result)
 		}
 
 		// Create data frame response
-		frame := data.NewFrame("response")
+		frame := newResponseFrame(result, settings.LegacyStringMode)
+		frame.RefID = query.RefID
+		frame.Name = responseFrameName(qm.FrameName, qm.Query, query.RefID)
+		setCustomMeta(frame, "executionTimeMs", time.Since(started).Milliseconds())
+		addConsoleLinks(frame, urlColumns)
+		for _, err := range subQueryErrs {
+			frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: err.Error()})
+		}
+		if timeRangeNotice != nil {
+			frame.AppendNotices(*timeRangeNotice)
+		}
+		if truncatedNotice != nil {
+			frame.AppendNotices(*truncatedNotice)
+		}
+		if settings.AlertOnSchemaDrift {
+			if changes := d.schemas.update(extractCollectionName(qm.Query), observedColumnTypes(result)); len(changes) > 0 {
+				frame.AppendNotices(schemaDriftNotice(changes))
+			}
+		}
+		if distances != nil {
+			frame.Fields = append(frame.Fields, data.NewField(distanceField, nil, distances))
+		}
+		if sources != nil {
+			frame.Fields = append(frame.Fields, data.NewField(sourceCollectionField, nil, sources))
+		}
+		if qm.IncludeDocumentMetadata {
+			if err := d.addDocumentTimes(ctx, pCtx, frame, extractCollectionName(qm.Query)); err != nil {
+				return firestoreErrorResponse("document metadata", err)
+			}
+		}
 
-		// Add a new column for document ID
-		docIDField := data.NewField("__document_id", nil, make([]*string, len(result.Records)))
-		frame.Fields = append(frame.Fields, docIDField)
+		if qm.TemporalDecayField != "" {
+			if err := addDecayWeights(frame, qm.TemporalDecayField, qm.DecayHalfLifeMinutes, query.TimeRange.To); err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		}
 
-		// Determine the maximum number of fields across all records
-		maxFields := 0
-		for _, record := range result.Records {
-			if len(record) > maxFields {
-				maxFields = len(record)
+		if qm.StaleDataWarningMinutes > 0 || qm.StaleDataTimeField != "" {
+			if err := addStaleDataNotice(frame, qm.StaleDataTimeField, qm.StaleDataWarningMinutes, time.Now()); err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
 			}
 		}
 
-		// Create fields with nil values for missing fields
-		for i := 0; i < maxFields; i++ {
-			var fieldName string
-			if i < len(result.Columns) {
-				fieldName = result.Columns[i]
-			} else {
-				fieldName = fmt.Sprintf("field_%d", i+1)
+		if qm.SequenceField != "" {
+			frame, err = addSequenceGaps(frame, qm.SequenceField, qm.ExpectedSequenceGap)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
 			}
+		}
 
-			field := data.NewField(fieldName, nil, make([]*string, len(result.Records)))
-			frame.Fields = append(frame.Fields, field)
-		}
-
-		// Populate field values for each record
-		for rowIdx, record := range result.Records {
-			// Extract document ID
-			var docID string
-			for colIdx, value := range record {
-				if colIdx < len(result.Columns) && strings.ToLower(result.Columns[colIdx]) == "__name__" {
-					if strValue, ok := value.(string); ok {
-						parts := strings.Split(strValue, "/")
-						docID = parts[len(parts)-1]
-					}
-					break
-				}
+		if len(qm.CompoundKeyFields) > 0 {
+			if err := addCompoundKey(frame, qm.CompoundKeyFields, qm.CompoundKeyName); err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
 			}
-			frame.Fields[0].Set(rowIdx, &docID)
-
-			for colIdx := 0; colIdx < maxFields; colIdx++ {
-				fieldIdx := colIdx + 1 // +1 because we added the document ID field
-				if colIdx < len(record) {
-					value := record[colIdx]
-					if timeValue, ok := value.(time.Time); ok {
-						// Convert time.Time to a string representation
-						strValue := timeValue.Format(time.RFC3339)
-						frame.Fields[fieldIdx].Set(rowIdx, &strValue)
-					} else if strValue, ok := value.(string); ok {
-						frame.Fields[fieldIdx].Set(rowIdx, &strValue)
-					} else {
-						// Convert other types to string representation
-						strValue := fmt.Sprintf("%v", value)
-						frame.Fields[fieldIdx].Set(rowIdx, &strValue)
-					}
-				} else {
-					frame.Fields[fieldIdx].Set(rowIdx, nil)
-				}
+		}
+
+		if qm.EnrichmentURL != "" {
+			if err := enrichFrame(ctx, frame, qm.EnrichmentURL, qm.EnrichmentKeyField, qm.EnrichmentMethod, settings.EnrichmentAllowedURLs); err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		}
+
+		if qm.TemporalSamplingPoints > 0 {
+			frame, err = sampleByTime(frame, qm.TemporalSamplingField, qm.TemporalSamplingPoints)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		}
+
+		if qm.CollapseByField != "" {
+			frame, err = collapseByField(frame, qm.CollapseByField, qm.CollapseAggregation)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		}
+
+		frame, err = applyTransformers(frame, qm.Transformers, qm.SortLocale)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+		projectFields(frame, qm.IncludeFields, qm.ExcludeFields)
+		applyDisplayNames(frame, qm.RenameFields)
+
+		if settings.SanitizeHTMLInStrings {
+			sanitizeHTMLStrings(frame)
+		}
+
+		if qm.LoadFieldDescriptions {
+			descriptions, err := d.fieldDescriptions(ctx, pCtx, settings, extractCollectionName(qm.Query))
+			if err != nil {
+				return firestoreErrorResponse("field descriptions", err)
+			}
+			applyFieldDescriptions(frame, descriptions)
+		}
+
+		if qm.LogMode {
+			frame, err = toLogFrame(frame, qm.TimeField, qm.MessageField, qm.LevelField)
+			if err != nil {
+				return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			}
+		} else if qm.AutoDetectLongFormat == nil || *qm.AutoDetectLongFormat {
+			markLongFormat(frame)
+		}
+		if qm.AddRowNumber {
+			addRowNumbers(frame)
+		}
+		applyFrameMetadata(frame, qm.FrameMetadata)
+		if settings.ETagSupport {
+			if err := d.tagFrame(datasourceCacheKey(pCtx, settings, string(query.JSON), query.RefID), frame); err != nil {
+				return backend.ErrDataResponse(backend.StatusInternal, err.Error())
 			}
 		}
 
 		// Add the frame to the response
 		response.Frames = append(response.Frames, frame)
+		if qm.ShowColumnStats {
+			response.Frames = append(response.Frames, newColumnStatsFrame(frame))
+		}
+
+		if qm.Explain && explainFrame == nil {
+			client, release, err := d.firestoreClient(pCtx)
+			if err != nil {
+				return firestoreErrorResponse("", err)
+			}
+			defer release()
+			explainFrame, err = explainQuery(ctx, client, qm.Query, !qm.ExplainPlanOnly, queryLimit(settings))
+			logAuditEvent(ctx, pCtx, qm.Query, extractCollectionName(qm.Query), 0, err)
+			if err != nil {
+				return firestoreErrorResponse("explain", err)
+			}
+		}
+		if explainFrame != nil {
+			response.Frames = append(response.Frames, explainFrame)
+		}
+	} else {
+		// Nothing to run: return an empty frame rather than an error
+		frame := data.NewFrame(responseFrameName(qm.FrameName, qm.Query, query.RefID))
+		frame.RefID = query.RefID
+		response.Frames = append(response.Frames, frame)
+	}
+
+	if settings.UseProtobuf {
+		if err := checkFrameEncoding(response.Frames); err != nil {
+			return backend.ErrDataResponse(backend.StatusInternal, err.Error())
+		}
 	}
 
 	return response
 }
 
+const (
+	documentIDField = "__document_id"
+	// documentNameColumn is the FireQL column holding the document name.
+	documentNameColumn = "__name__"
+
+	// reservedFieldPrefix marks field names injected by the plugin. Firestore
+	// fields using it are prefixed with reservedFieldRenamePrefix.
+	reservedFieldPrefix       = "__"
+	reservedFieldRenamePrefix = "firestore_"
+)
+
+// newResponseFrame builds a data frame from the records returned by fireql,
+// prepending the plugin-injected document ID column. Columns are typed from
+// their values unless legacyStrings is set, in which case every value is
+// formatted as a string as in earlier versions of the plugin.
+func newResponseFrame(result *util.QueryResult, legacyStrings bool) *data.Frame {
+	frame := data.NewFrame("response")
+
+	// Add a new column for document ID
+	docIDField := data.NewField(documentIDField, nil, make([]*string, len(result.Records)))
+	frame.Fields = append(frame.Fields, docIDField)
+
+	// Determine the maximum number of fields across all records
+	maxFields := 0
+	for _, record := range result.Records {
+		if len(record) > maxFields {
+			maxFields = len(record)
+		}
+	}
+
+	// Collect the values of each column; missing values are left nil
+	var renamed []string
+	names := make([]string, maxFields)
+	columns := make([][]interface{}, maxFields)
+	for i := 0; i < maxFields; i++ {
+		var fieldName string
+		if i < len(result.Columns) {
+			fieldName = result.Columns[i]
+		} else {
+			fieldName = fmt.Sprintf("field_%d", i+1)
+		}
+		// Firestore fields must not collide with the fields injected by the
+		// plugin. __name__, the document name selected through FireQL, keeps
+		// its name as dashboards and variables refer to it.
+		if strings.HasPrefix(fieldName, reservedFieldPrefix) && fieldName != documentNameColumn {
+			renamed = append(renamed, fieldName)
+			fieldName = reservedFieldRenamePrefix + fieldName
+		}
+		names[i] = fieldName
+		columns[i] = make([]interface{}, len(result.Records))
+	}
+
+	fieldErrors := 0
+	for rowIdx, record := range result.Records {
+		// Extract document ID
+		var docID string
+		for colIdx, value := range record {
+			if colIdx < len(result.Columns) && strings.ToLower(result.Columns[colIdx]) == "__name__" {
+				if strValue, ok := value.(string); ok {
+					parts := strings.Split(strValue, "/")
+					docID = parts[len(parts)-1]
+				}
+				break
+			}
+		}
+		frame.Fields[0].Set(rowIdx, &docID)
+
+		for colIdx, value := range record {
+			var v interface{}
+			var err error
+			if legacyStrings && value != nil {
+				v, err = formatValue(value)
+			} else {
+				v, err = frameValue(value)
+			}
+			if err != nil {
+				// Skip the value rather than failing the whole row
+				log.DefaultLogger.Warn("Could not process field value", "field", names[colIdx], "row", rowIdx, "error", err)
+				fieldErrors++
+				continue
+			}
+			columns[colIdx][rowIdx] = v
+		}
+	}
+
+	// Build a typed field from the values of each column
+	for i, name := range names {
+		if legacyStrings {
+			frame.Fields = append(frame.Fields, stringField(name, columns[i]))
+			continue
+		}
+		field, err := createTypedField(name, columns[i], len(result.Records))
+		if err != nil {
+			log.DefaultLogger.Warn("Could not build typed field, falling back to strings", "field", name, "error", err)
+			field = stringField(name, columns[i])
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	if fieldErrors > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("%d field values could not be processed; check server logs.", fieldErrors),
+		})
+	}
+
+	if len(renamed) > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Fields %s conflict with plugin field names and were renamed with the %q prefix", strings.Join(renamed, ", "), reservedFieldRenamePrefix),
+		})
+	}
+
+	return frame
+}
+
+// frameValue returns the value stored in the frame for a Firestore value.
+// Scalars and timestamps are kept so createTypedField can type their column,
+// maps and arrays are encoded as JSON and any other value is formatted as a
+// string.
+func frameValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool, int, int8, int16, int32, int64, float32, float64, string, time.Time:
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return formatValue(value)
+}
+
+// stringField returns a nullable string field holding the string
+// representation of values.
+func stringField(name string, values []interface{}) *data.Field {
+	field := data.NewField(name, nil, make([]*string, len(values)))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		s, err := formatValue(v)
+		if err != nil {
+			continue
+		}
+		field.Set(i, &s)
+	}
+	return field
+}
+
+// formatValue returns the string representation of a Firestore value.
+// A panic while formatting the value is returned as an error.
+func formatValue(value interface{}) (str string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic formatting %T: %v", value, r)
+		}
+	}()
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339), nil
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	// Convert other types to string representation
+	return fmt.Sprintf("%v", value), nil
+}
+
 //////////////////////////////////
 
 func createTypedField(name string, values []interface{}, length int) (*data.Field, error) {
@@ -208,73 +1140,96 @@ func createTypedField(name string, values []interface{}, length int) (*data.Fiel
 	allInt := true
 	allFloat := true
 	allTime := true
+	allNil := true
 
-	for i := 0; i < length; i++ {
-		if i >= len(values) {
-			// Handle case when i is out of range for values
-			break
-		}
-
-		v := values[i]
-		switch val := v.(type) {
+	for i := 0; i < length && i < len(values); i++ {
+		var str string
+		switch val := values[i].(type) {
+		case nil:
+			// Handle null values
+			continue
 		case bool:
 			boolVals[i] = &val
-		case int, int32, int64:
-			intVal := val.(int64) // Type assertion to int64
+			str = strconv.FormatBool(val)
+			allInt = false
+			allFloat = false
+			allTime = false
+		case int, int8, int16, int32, int64:
+			intVal := reflect.ValueOf(val).Int()
+			floatVal := float64(intVal)
 			intVals[i] = &intVal
+			floatVals[i] = &floatVal
+			str = strconv.FormatInt(intVal, 10)
+			allBool = false
+			allTime = false
 		case float32, float64:
-			floatVal := val.(float64) // Type assertion to float64
+			floatVal := reflect.ValueOf(val).Float()
 			floatVals[i] = &floatVal
+			str = strconv.FormatFloat(floatVal, 'f', -1, 64)
+			allBool = false
 			allInt = false
+			allTime = false
 		case string:
-			stringVals[i] = &val
+			str = val
 			allBool = false
 			allInt = false
 			allFloat = false
 			allTime = false
 		case time.Time:
 			timeVals[i] = &val
+			str = val.Format(time.RFC3339Nano)
 			allBool = false
 			allInt = false
 			allFloat = false
-		case nil:
-			// Handle null values
 		default:
 			// For complex types, convert to JSON string
 			jsonVal, err := json.Marshal(val)
 			if err != nil {
 				return nil, fmt.Errorf("error marshaling value to JSON: %v", err)
 			}
-			strVal := string(jsonVal)
-			stringVals[i] = &strVal
+			str = string(jsonVal)
 			allBool = false
 			allInt = false
 			allFloat = false
 			allTime = false
 		}
+		// Keep the string representation for mixed columns
+		stringVals[i] = &str
+		allNil = false
 	}
 
-	if allBool {
+	switch {
+	case allNil:
+		// Columns without any value cannot be typed
+		return data.NewField(name, nil, stringVals), nil
+	case allBool:
 		return data.NewField(name, nil, boolVals), nil
-	}
-	if allInt {
+	case allInt:
 		return data.NewField(name, nil, intVals), nil
-	}
-	if allFloat {
+	case allFloat:
+		// Integers mixed with floats are widened to float64
 		return data.NewField(name, nil, floatVals), nil
-	}
-	if allTime {
+	case allTime:
 		return data.NewField(name, nil, timeVals), nil
 	}
 
 	return data.NewField(name, nil, stringVals), nil
 }
 
-
-
 ///////////////////////////////////////////
 
+// newFirestoreClient creates a client authenticated with a service account
+// picked by selectServiceAccount.
 func newFirestoreClient(ctx context.Context, pCtx backend.PluginContext) (*firestore.Client, error) {
+	// Invalid settings are reported by newServiceAccountClient
+	var settings FirestoreSettings
+	_ = json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings)
+	return newServiceAccountClient(ctx, pCtx, selectServiceAccount(settings, pCtx))
+}
+
+// newServiceAccountClient creates a client for the datasource settings. The
+// serviceAccount JSON is used unless another credential source is configured.
+func newServiceAccountClient(ctx context.Context, pCtx backend.PluginContext, serviceAccount string) (*firestore.Client, error) {
 	var settings FirestoreSettings
 	err := json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings)
 	if err != nil {
@@ -282,14 +1237,32 @@ func newFirestoreClient(ctx context.Context, pCtx backend.PluginContext) (*fires
 		return nil, fmt.Errorf("ProjectID: %v", err)
 	}
 
+	if len(settings.ProjectId) == 0 {
+		settings.ProjectId = projectIDFromEnvironment()
+	}
 	if len(settings.ProjectId) == 0 {
 		return nil, errors.New("project Id is required")
 	}
+	if err := checkProjectAllowed(settings, settings.ProjectId); err != nil {
+		return nil, err
+	}
 
 	var options []option.ClientOption
-	serviceAccount := pCtx.DataSourceInstanceSettings.DecryptedSecureJSONData["serviceAccount"]
+	emulatorHost := emulatorHost(settings)
 
-	if len(serviceAccount) > 0 {
+	if settings.WorkloadIdentityConfigFile != "" && emulatorHost == "" {
+		creds, err := workloadIdentityCredentials(ctx, settings.WorkloadIdentityConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option.WithCredentials(creds))
+	} else if settings.UseApplicationDefaultCredentials && emulatorHost == "" {
+		adc, err := defaultCredentialsOption(ctx)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, adc)
+	} else if len(serviceAccount) > 0 && emulatorHost == "" {
 		if !json.Valid([]byte(serviceAccount)) {
 			return nil, errors.New("invalid service account, it is expected to be a JSON")
 		}
@@ -303,8 +1276,23 @@ func newFirestoreClient(ctx context.Context, pCtx backend.PluginContext) (*fires
 		options = append(options, option.WithCredentials(creds))
 	}
 
-	client, err := firestore.NewClientWithDatabase(ctx, settings.ProjectId, settings.DatabaseName, options...)
+	if emulatorHost == "" {
+		tlsOptions, err := tlsClientOptions(settings)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, tlsOptions...)
+	}
+	if settings.EmulatorHost != "" {
+		options = append(options, emulatorClientOptions(settings.EmulatorHost)...)
+	}
 
+	var client *firestore.Client
+	if settings.DatabaseName == "" {
+		client, err = firestore.NewClient(ctx, settings.ProjectId, options...)
+	} else {
+		client, err = firestore.NewClientWithDatabase(ctx, settings.ProjectId, settings.DatabaseName, options...)
+	}
 	if err != nil {
 		log.DefaultLogger.Error("firestore.NewClient ", err)
 		return nil, fmt.Errorf("firestore.NewClient: %v", err)
@@ -318,27 +1306,45 @@ func (d *Datasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRe
 	var status = backend.HealthStatusOk
 	var message = "Data source is working"
 
-	client, healthErr := newFirestoreClient(ctx, req.PluginContext)
-
-	if healthErr == nil {
-		defer client.Close()
-		collections := client.Collections(ctx)
-		collection, err := collections.Next()
-		if err == nil || errors.Is(err, iterator.Done) {
-			log.DefaultLogger.Debug("First collections: ", collection.ID)
-		} else {
-			log.DefaultLogger.Error("client.Collections ", err)
-			healthErr = fmt.Errorf("firestore.Collections: %v", err)
-		}
+	var settings FirestoreSettings
+	_ = json.Unmarshal(req.PluginContext.DataSourceInstanceSettings.JSONData, &settings)
+	details := healthDetails{
+		ProjectID:    settings.ProjectId,
+		DatabaseName: settings.DatabaseName,
+		AuthMethod:   authMethod(settings, req.PluginContext),
+		SDKVersion:   firestoreSDKVersion(),
+	}
+	if details.ProjectID == "" {
+		details.ProjectID = projectIDFromEnvironment()
+	}
+	if details.DatabaseName == "" {
+		details.DatabaseName = firestore.DefaultDatabaseID
 	}
 
+	started := time.Now()
+	collectionCount, healthErr := d.checkFirestore(ctx, req.PluginContext)
+	details.ConnectionLatencyMs = time.Since(started).Milliseconds()
+
 	if healthErr != nil {
 		status = backend.HealthStatusError
 		message = healthErr.Error()
+		details.setError(healthErr, settings, req.PluginContext)
+	} else {
+		if collectionCount != uncountedCollections {
+			details.CollectionCount = &collectionCount
+		}
+		if settings.EmulatorHost != "" {
+			message += ". Connected to Firestore emulator at " + settings.EmulatorHost
+		}
 	}
 
+	jsonDetails, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
 	return &backend.CheckHealthResult{
-		Status:  status,
-		Message: message,
+		Status:      status,
+		Message:     message,
+		JSONDetails: jsonDetails,
 	}, nil
-}
\ No newline at end of file
+}
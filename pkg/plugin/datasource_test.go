@@ -14,8 +14,12 @@ import (
 	"sync"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
 )
 
 func TestQueryData(t *testing.T) {
@@ -65,6 +69,270 @@ func TestQueryData(t *testing.T) {
 	}
 }
 
+func TestQueryDataTimestamps(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	createdAt := time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	_, err := client.Collection("timestamp_events").Doc("e1").Set(ctx, map[string]interface{}{"createdAt": createdAt})
+	require.NoError(t, err)
+
+	resp := runQuery(t, &Datasource{}, FirestoreSettings{ProjectId: "test"}, `{"query": "select createdAt from timestamp_events"}`)
+	require.NoError(t, resp.Error)
+	field, _ := resp.Frames[0].FieldByName("createdAt")
+	require.NotNil(t, field)
+	require.Equal(t, data.FieldTypeNullableTime, field.Type())
+	require.True(t, createdAt.Equal(*field.At(0).(*time.Time)))
+	require.Equal(t, createdAt.Nanosecond(), field.At(0).(*time.Time).Nanosecond())
+}
+
+func TestNewResponseFrameReservedFieldNames(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"__name__", "__document_id", "name"},
+		Records: [][]interface{}{
+			{"projects/test/databases/(default)/documents/users/abc", "shadowed", "Leanne"},
+		},
+	}
+
+	frame := newResponseFrame(result, false)
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, "__document_id", frame.Fields[0].Name)
+	require.Equal(t, "abc", *frame.Fields[0].At(0).(*string))
+	require.Equal(t, "__name__", frame.Fields[1].Name)
+	require.Equal(t, "firestore___document_id", frame.Fields[2].Name)
+	require.Equal(t, "shadowed", *frame.Fields[2].At(0).(*string))
+	require.Equal(t, "name", frame.Fields[3].Name)
+	require.Len(t, frame.Meta.Notices, 1)
+}
+
+// panickingValue panics when formatted.
+type panickingValue struct{}
+
+func (panickingValue) String() string {
+	panic("cannot format")
+}
+
+func TestNewResponseFramePartialFieldFailure(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"name", "broken", "age"},
+		Records: [][]interface{}{
+			{"Leanne", panickingValue{}, int64(31)},
+			{"Ervin", "fine", int64(42)},
+		},
+	}
+
+	frame := newResponseFrame(result, false)
+	require.Equal(t, 2, frame.Rows())
+	require.Equal(t, "Leanne", *frame.Fields[1].At(0).(*string))
+	require.Nil(t, frame.Fields[2].At(0))
+	require.Equal(t, int64(31), *frame.Fields[3].At(0).(*int64))
+	require.Equal(t, "fine", *frame.Fields[2].At(1).(*string))
+	require.Equal(t, []data.Notice{{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "1 field values could not be processed; check server logs.",
+	}}, frame.Meta.Notices)
+}
+
+func TestNewResponseFrameTypedFields(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	result := &util.QueryResult{
+		Columns: []string{"age", "createdAt", "active", "score", "deleted", "tags", "mixed"},
+		Records: [][]interface{}{
+			{int64(31), createdAt, true, 1.5, nil, []interface{}{"a", "b"}, "x"},
+			{nil, createdAt.Add(time.Hour), false, 2.5, nil, nil, true},
+		},
+	}
+
+	frame := newResponseFrame(result, false)
+	require.Equal(t, int64(31), *frame.Fields[1].At(0).(*int64))
+	require.Nil(t, frame.Fields[1].At(1))
+	require.Equal(t, createdAt, *frame.Fields[2].At(0).(*time.Time))
+	require.Equal(t, true, *frame.Fields[3].At(0).(*bool))
+	require.Equal(t, 2.5, *frame.Fields[4].At(1).(*float64))
+	require.Equal(t, data.FieldTypeNullableString, frame.Fields[5].Type())
+	require.Equal(t, `["a","b"]`, *frame.Fields[6].At(0).(*string))
+	require.Equal(t, data.FieldTypeNullableString, frame.Fields[7].Type())
+}
+
+func TestCreateTypedField(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   interface{}
+	}{
+		{"int", []interface{}{1, nil}, []*int64{ptrTo(int64(1)), nil}},
+		{"int32", []interface{}{int32(2)}, []*int64{ptrTo(int64(2))}},
+		{"int64", []interface{}{int64(3)}, []*int64{ptrTo(int64(3))}},
+		{"float32", []interface{}{float32(1.5)}, []*float64{ptrTo(1.5)}},
+		{"float64", []interface{}{2.5}, []*float64{ptrTo(2.5)}},
+		{"bool", []interface{}{true, false}, []*bool{ptrTo(true), ptrTo(false)}},
+		{"string", []interface{}{"a"}, []*string{str("a")}},
+		{"time", []interface{}{createdAt}, []*time.Time{&createdAt}},
+		{"nil", []interface{}{nil, nil}, []*string{nil, nil}},
+		{"int and float", []interface{}{1, 2.5, nil}, []*float64{ptrTo(1.0), ptrTo(2.5), nil}},
+		{"mixed", []interface{}{"a", 1, true, createdAt, nil},
+			[]*string{str("a"), str("1"), str("true"), str("2024-03-01T12:00:00Z"), nil}},
+		{"object", []interface{}{map[string]interface{}{"a": 1}}, []*string{str(`{"a":1}`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, err := createTypedField("value", tt.values, len(tt.values))
+			require.NoError(t, err)
+			want := data.NewField("value", nil, tt.want)
+			require.Equal(t, want.Type(), field.Type())
+			for i := 0; i < want.Len(); i++ {
+				require.Equal(t, want.At(i), field.At(i))
+			}
+		})
+	}
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestLegacyStringMode(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"age"}, Records: [][]interface{}{{int64(31)}, {nil}}}}}
+	ds := newMockDatasource(mock)
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test", LegacyStringMode: true}, `{"query": "select age from users"}`)
+	require.NoError(t, resp.Error)
+	age, _ := resp.Frames[0].FieldByName("age")
+	require.Equal(t, data.FieldTypeNullableString, age.Type())
+	require.Equal(t, "31", *age.At(0).(*string))
+	require.Nil(t, age.At(1))
+
+	resp = runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, `{"query": "select age from users"}`)
+	require.NoError(t, resp.Error)
+	age, _ = resp.Frames[0].FieldByName("age")
+	require.Equal(t, data.FieldTypeNullableInt64, age.Type())
+}
+
+func TestProjectIDFromEnvironment(t *testing.T) {
+	for _, name := range projectIDEnvVars {
+		t.Setenv(name, "")
+	}
+	require.Equal(t, "", projectIDFromEnvironment())
+
+	t.Setenv("GCLOUD_PROJECT_ID", "from-project-id")
+	require.Equal(t, "from-project-id", projectIDFromEnvironment())
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "from-google-cloud-project")
+	require.Equal(t, "from-google-cloud-project", projectIDFromEnvironment())
+}
+
+func TestQueryDataProjectIDFromEnvironment(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "test")
+
+	ds := Datasource{}
+	resp, err := ds.QueryData(
+		context.Background(),
+		&backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					JSONData: []byte(`{}`),
+				},
+			},
+			Queries: []backend.DataQuery{
+				{RefID: "A", JSON: []byte(`{"query": "select * from users"}`)},
+			},
+		},
+	)
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, resp.Responses["A"].Frames, 1)
+}
+
+func TestAllowedProjectIDs(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}, Records: [][]interface{}{{"1"}}}}}
+	ds := newMockDatasource(mock)
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "prod", AllowedProjectIDs: []string{"prod", "staging"}},
+		`{"query": "select id from users"}`)
+	require.NoError(t, resp.Error)
+
+	resp = runQuery(t, ds, FirestoreSettings{ProjectId: "other", AllowedProjectIDs: []string{"prod", "staging"}},
+		`{"query": "select id from users"}`)
+	require.Error(t, resp.Error)
+	require.Equal(t, backend.StatusUnauthorized, resp.Status)
+	require.Equal(t, 1, mock.calls())
+}
+
+func TestDefaultQuery(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}, Records: [][]interface{}{{"1"}, {"2"}}}}}
+	ds := newMockDatasource(mock)
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test", DefaultQuery: "select id from users"}, `{}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, resp.Frames[0].Rows())
+	require.Equal(t, []string{"select id from users"}, mock.queries)
+
+	resp = runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, `{}`)
+	require.NoError(t, resp.Error)
+	require.Len(t, resp.Frames, 1)
+	require.Equal(t, 0, resp.Frames[0].Rows())
+	require.Equal(t, 1, mock.calls())
+}
+
+// mockExecutor replays canned results and errors, one per Execute call. The
+// last entry is repeated once the canned values are exhausted.
+type mockExecutor struct {
+	mu      sync.Mutex
+	queries []string
+	results []*util.QueryResult
+	errs    []error
+}
+
+func (m *mockExecutor) Execute(query string) (*util.QueryResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	call := len(m.queries)
+	m.queries = append(m.queries, query)
+
+	var result *util.QueryResult
+	if len(m.results) > 0 {
+		result = m.results[min(call, len(m.results)-1)]
+	}
+	var err error
+	if len(m.errs) > 0 {
+		err = m.errs[min(call, len(m.errs)-1)]
+	}
+	return result, err
+}
+
+func (m *mockExecutor) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queries)
+}
+
+// newMockDatasource returns a Datasource executing all FireQL queries on m.
+func newMockDatasource(m *mockExecutor) *Datasource {
+	return &Datasource{
+		newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+			return m, nil
+		},
+	}
+}
+
+// runQuery executes a single query with the given datasource settings.
+func runQuery(t *testing.T, ds *Datasource, settings FirestoreSettings, queryJSON string) backend.DataResponse {
+	jsonSettings, err := json.Marshal(settings)
+	require.NoError(t, err)
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(queryJSON)}},
+	})
+	require.NoError(t, err)
+	return resp.Responses["A"]
+}
+
 type healthTest struct {
 	settings  string
 	decrypted map[string]string
@@ -306,3 +574,38 @@ func TestMain(m *testing.M) {
 //func first(n int, _ error) int {
 //	return n
 //}
+
+func TestResponseFrameRefIDAndName(t *testing.T) {
+	newResult := func() *util.QueryResult {
+		return &util.QueryResult{Columns: []string{"value"}, Records: [][]interface{}{{int64(1)}}}
+	}
+	mock := &mockExecutor{results: []*util.QueryResult{newResult(), newResult()}}
+	ds := newMockDatasource(mock)
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := runQuery(t, ds, settings, `{"query": "select value from metrics"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, "A", resp.Frames[0].RefID)
+	require.Equal(t, "metrics", resp.Frames[0].Name)
+
+	resp = runQuery(t, ds, settings, `{"query": "select value from metrics", "frameName": "cpu"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, "A", resp.Frames[0].RefID)
+	require.Equal(t, "cpu", resp.Frames[0].Name)
+
+	require.Equal(t, "orders", responseFrameName("", "select * from `[orders]`", "A"))
+	require.Equal(t, "A", responseFrameName("", "", "A"))
+}
+
+func TestEmptyQueryFrameName(t *testing.T) {
+	ds := newMockDatasource(&mockExecutor{})
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := runQuery(t, ds, settings, `{"query": ""}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, "A", resp.Frames[0].RefID)
+	require.Equal(t, "A", resp.Frames[0].Name)
+
+	resp = runQuery(t, ds, settings, `{"query": "", "frameName": "cpu"}`)
+	require.Equal(t, "cpu", resp.Frames[0].Name)
+}
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// statusCancelled is the status of cancelled queries, the "client closed
+	// request" status used by Grafana.
+	statusCancelled backend.Status = 499
+	// defaultQueryTimeout bounds queries whose request carries no deadline.
+	defaultQueryTimeout = 30 * time.Second
+	// maxDeadlineExtension caps FirestoreSettings.DeadlineExtensionSecs.
+	maxDeadlineExtension = 60 * time.Second
+)
+
+// queryContext returns the context queries run with. The deadline set by
+// Grafana on the request is kept as is, unless DeadlineExtensionSecs extends
+// it; requests without a deadline time out after defaultQueryTimeout. An
+// extended context is still cancelled when the request is.
+func queryContext(ctx context.Context, settings FirestoreSettings) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithTimeout(ctx, defaultQueryTimeout)
+	}
+	if settings.DeadlineExtensionSecs <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	extension := time.Duration(settings.DeadlineExtensionSecs) * time.Second
+	if extension > maxDeadlineExtension {
+		extension = maxDeadlineExtension
+	}
+	extended, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline.Add(extension))
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cancel()
+		}
+	})
+	return extended, func() {
+		stop()
+		cancel()
+	}
+}
+
+// queryTimeout returns how long a query may run: its TimeoutSeconds, or the
+// DefaultTimeoutSeconds of the datasource, or defaultQueryTimeout. It returns
+// 0, for no timeout of its own, when DeadlineExtensionSecs is set without
+// either, so that the extended deadline of queryContext applies.
+func queryTimeout(qm FirestoreQuery, settings FirestoreSettings) time.Duration {
+	switch {
+	case qm.TimeoutSeconds > 0:
+		return time.Duration(qm.TimeoutSeconds) * time.Second
+	case settings.DefaultTimeoutSeconds > 0:
+		return time.Duration(settings.DefaultTimeoutSeconds) * time.Second
+	case settings.DeadlineExtensionSecs > 0:
+		return 0
+	}
+	return defaultQueryTimeout
+}
+
+// isTimeout reports whether err is due to a deadline being exceeded.
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+// isCancelled reports whether err is due to the query being cancelled, e.g.
+// when the dashboard requesting it is closed.
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled
+}
+
+// executeWithContext executes the query, returning early with the error of
+// ctx when it is done first. Executors implementing contextExecutor cancel
+// the query itself; fireql does not take a context, so its queries keep
+// running in the background until they complete.
+func executeWithContext(ctx context.Context, executor queryExecutor, query string) (*util.QueryResult, error) {
+	if ce, ok := executor.(contextExecutor); ok {
+		result, err := ce.ExecuteContext(ctx, query)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return result, err
+	}
+
+	type outcome struct {
+		result *util.QueryResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := executor.Execute(query)
+		done <- outcome{result, err}
+	}()
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
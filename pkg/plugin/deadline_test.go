@@ -0,0 +1,180 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestQueryContextHonorsDeadline(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelParent()
+	want, _ := parent.Deadline()
+
+	ctx, cancel := queryContext(parent, FirestoreSettings{})
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, want, deadline)
+
+	select {
+	case <-ctx.Done():
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadline not honored")
+	}
+}
+
+func TestQueryContextDefaultTimeout(t *testing.T) {
+	ctx, cancel := queryContext(context.Background(), FirestoreSettings{})
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(defaultQueryTimeout), deadline, time.Second)
+}
+
+func TestQueryContextDeadlineExtension(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	want, _ := parent.Deadline()
+
+	ctx, cancel := queryContext(parent, FirestoreSettings{DeadlineExtensionSecs: 600})
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, want.Add(maxDeadlineExtension), deadline)
+
+	// Cancelling the request still cancels the extended context
+	cancelParent()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("request cancellation not propagated")
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	require.Equal(t, defaultQueryTimeout, queryTimeout(FirestoreQuery{}, FirestoreSettings{}))
+	require.Equal(t, 10*time.Second, queryTimeout(FirestoreQuery{}, FirestoreSettings{DefaultTimeoutSeconds: 10}))
+	require.Equal(t, 5*time.Second, queryTimeout(FirestoreQuery{TimeoutSeconds: 5}, FirestoreSettings{DefaultTimeoutSeconds: 10}))
+	require.Equal(t, time.Duration(0), queryTimeout(FirestoreQuery{}, FirestoreSettings{DeadlineExtensionSecs: 60}))
+	require.Equal(t, 5*time.Second, queryTimeout(FirestoreQuery{TimeoutSeconds: 5}, FirestoreSettings{DeadlineExtensionSecs: 60}))
+}
+
+// deadlineExecutor records the deadline of the context of its queries.
+type deadlineExecutor struct {
+	deadline time.Time
+}
+
+func (e *deadlineExecutor) Execute(query string) (*util.QueryResult, error) {
+	return e.ExecuteContext(context.Background(), query)
+}
+
+func (e *deadlineExecutor) ExecuteContext(ctx context.Context, _ string) (*util.QueryResult, error) {
+	e.deadline, _ = ctx.Deadline()
+	return &util.QueryResult{Columns: []string{"name"}}, nil
+}
+
+func TestQueryTimeoutWithDeadlineExtension(t *testing.T) {
+	executor := &deadlineExecutor{}
+	ds := &Datasource{newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+		return executor, nil
+	}}
+	run := func(settings FirestoreSettings, query string) {
+		jsonSettings, err := json.Marshal(settings)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		resp, err := ds.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings}},
+			Queries:       []backend.DataQuery{{RefID: "A", JSON: []byte(query)}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+	}
+
+	// The extended deadline is not cut short by the default timeout
+	run(FirestoreSettings{ProjectId: "test", DeadlineExtensionSecs: 60}, `{"query": "select name from users"}`)
+	require.WithinDuration(t, time.Now().Add(61*time.Second), executor.deadline, 2*time.Second)
+
+	// An explicit timeout still applies within the extended deadline
+	run(FirestoreSettings{ProjectId: "test", DeadlineExtensionSecs: 60}, `{"query": "select name from users", "timeoutSeconds": 5}`)
+	require.WithinDuration(t, time.Now().Add(5*time.Second), executor.deadline, 2*time.Second)
+}
+
+func TestQueryTimesOut(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(executor.release)
+	ds := &Datasource{newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+		return executor, nil
+	}}
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, `{"query": "select name from users", "timeoutSeconds": 1}`)
+	require.Equal(t, backend.StatusTimeout, resp.Status)
+	require.EqualError(t, resp.Error, "query timed out after 1 seconds")
+}
+
+// cancellableExecutor blocks until the context of its query is done.
+type cancellableExecutor struct {
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func (e *cancellableExecutor) Execute(query string) (*util.QueryResult, error) {
+	return e.ExecuteContext(context.Background(), query)
+}
+
+func (e *cancellableExecutor) ExecuteContext(ctx context.Context, _ string) (*util.QueryResult, error) {
+	e.started <- struct{}{}
+	<-ctx.Done()
+	close(e.stopped)
+	return nil, status.Error(codes.Canceled, "context canceled")
+}
+
+func TestQueryCancelled(t *testing.T) {
+	executor := &cancellableExecutor{started: make(chan struct{}, 1), stopped: make(chan struct{})}
+	ds := &Datasource{newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+		return executor, nil
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-executor.started
+		cancel()
+	}()
+	resp, err := ds.QueryData(ctx, &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"ProjectId": "test"}`)},
+		},
+		Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"query": "select name from users"}`)}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, statusCancelled, resp.Responses["A"].Status)
+	require.EqualError(t, resp.Responses["A"].Error, "query cancelled")
+
+	select {
+	case <-executor.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the query kept running after being cancelled")
+	}
+}
+
+func TestQueryCancelledWithoutContext(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(executor.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-executor.started
+		cancel()
+	}()
+	_, err := executeWithContext(ctx, executor, "select name from users")
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const weightField = "__weight"
+
+// addDecayWeights appends a weightField column weighting each row by the age
+// of its timeField value relative to now, halving every halfLifeMinutes.
+// Rows without a parsable time get a nil weight.
+func addDecayWeights(frame *data.Frame, timeField string, halfLifeMinutes float64, now time.Time) error {
+	if halfLifeMinutes <= 0 {
+		return fmt.Errorf("DecayHalfLifeMinutes must be positive, got %v", halfLifeMinutes)
+	}
+	field, _ := frame.FieldByName(timeField)
+	if field == nil {
+		return fmt.Errorf("temporal decay field %q not found", timeField)
+	}
+
+	weights := make([]*float64, field.Len())
+	for i := range weights {
+		t, ok := timeValue(field.At(i))
+		if !ok {
+			continue
+		}
+		ageMinutes := now.Sub(t).Minutes()
+		weight := math.Exp(-math.Ln2 * ageMinutes / halfLifeMinutes)
+		weights[i] = &weight
+	}
+	frame.Fields = append(frame.Fields, data.NewField(weightField, nil, weights))
+	return nil
+}
+
+// timeValue returns the time of a frame value, parsing RFC 3339 strings.
+func timeValue(v interface{}) (time.Time, bool) {
+	switch v := derefValue(v).(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,31 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddDecayWeights(t *testing.T) {
+	to := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := to.Format(time.RFC3339)
+	halfLife := to.Add(-30 * time.Minute).Format(time.RFC3339)
+	invalid := "yesterday"
+	frame := data.NewFrame("response", data.NewField("created", nil, []*string{&now, &halfLife, &invalid, nil}))
+
+	require.NoError(t, addDecayWeights(frame, "created", 30, to))
+	weights := frame.Fields[1]
+	require.Equal(t, weightField, weights.Name)
+	require.InDelta(t, 1, *weights.At(0).(*float64), 1e-9)
+	require.InDelta(t, 0.5, *weights.At(1).(*float64), 1e-9)
+	require.Nil(t, weights.At(2))
+	require.Nil(t, weights.At(3))
+}
+
+func TestAddDecayWeightsErrors(t *testing.T) {
+	frame := data.NewFrame("response", data.NewField("created", nil, []*string{}))
+	require.Error(t, addDecayWeights(frame, "missing", 30, time.Now()))
+	require.Error(t, addDecayWeights(frame, "created", 0, time.Now()))
+}
@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// inflightQueries shares the response of a query with the identical queries
+// received while it runs. The zero value is ready to use.
+type inflightQueries struct {
+	// calls holds *inflightCall values by query signature.
+	calls sync.Map
+}
+
+type inflightCall struct {
+	// done is closed once response is set.
+	done     chan struct{}
+	response backend.DataResponse
+}
+
+// querySignature identifies a query by its RefID, JSON model and time range.
+func querySignature(query backend.DataQuery) string {
+	h := sha256.New()
+	h.Write([]byte(query.RefID))
+	h.Write([]byte{0})
+	h.Write(query.JSON)
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatInt(query.TimeRange.From.UnixNano(), 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatInt(query.TimeRange.To.UnixNano(), 10)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// do returns the response of run, unless a call with the same key is already
+// running, in which case its response is awaited and returned instead.
+func (q *inflightQueries) do(ctx context.Context, key string, run func() backend.DataResponse) backend.DataResponse {
+	call := &inflightCall{done: make(chan struct{})}
+	if existing, loaded := q.calls.LoadOrStore(key, call); loaded {
+		log.DefaultLogger.Debug("Waiting for identical in-flight query", "signature", key)
+		existing := existing.(*inflightCall)
+		select {
+		case <-existing.done:
+			return existing.response
+		case <-ctx.Done():
+			return backend.ErrDataResponse(backend.StatusTimeout, ctx.Err().Error())
+		}
+	}
+
+	defer func() {
+		q.calls.Delete(key)
+		close(call.done)
+	}()
+	call.response = run()
+	return call.response
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingExecutor counts its calls and blocks them until release is closed.
+type blockingExecutor struct {
+	mu      sync.Mutex
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingExecutor) Execute(string) (*util.QueryResult, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	e.started <- struct{}{}
+	<-e.release
+	return &util.QueryResult{Columns: []string{"name"}, Records: [][]interface{}{{"alice"}}}, nil
+}
+
+func TestQueryRequestDedup(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{}, 2), release: make(chan struct{})}
+	ds := &Datasource{newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+		return executor, nil
+	}}
+	settings := FirestoreSettings{ProjectId: "test", RequestDedup: true}
+	query := `{"query": "select name from users"}`
+
+	var wg sync.WaitGroup
+	responses := make([]backend.DataResponse, 2)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = runQuery(t, ds, settings, query)
+		}(i)
+		if i == 0 {
+			<-executor.started
+		}
+	}
+	// Let the second query reach the in-flight call before releasing it
+	time.Sleep(100 * time.Millisecond)
+	close(executor.release)
+	wg.Wait()
+
+	require.Equal(t, 1, executor.calls)
+	for _, resp := range responses {
+		require.NoError(t, resp.Error)
+		require.Equal(t, "alice", *resp.Frames[0].Fields[1].At(0).(*string))
+	}
+
+	// Later identical queries run again
+	resp := runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, executor.calls)
+}
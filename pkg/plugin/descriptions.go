@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// fieldDescriptionsCollection holds one document per collection whose
+	// string fields describe the fields of that collection.
+	fieldDescriptionsCollection = "_meta"
+	fieldDescriptionsTTL        = 5 * time.Minute
+)
+
+type fieldDescriptionsEntry struct {
+	descriptions map[string]string
+	expires      time.Time
+}
+
+// fieldDescriptions returns the field descriptions stored in the
+// _meta/<collection> document, caching them for fieldDescriptionsTTL per
+// datasource and database. The slashes of sub-collection paths are escaped
+// in the document ID, e.g. _meta/users%2Fabc%2Forders. A missing document
+// yields no descriptions.
+func (d *Datasource) fieldDescriptions(ctx context.Context, pCtx backend.PluginContext, settings FirestoreSettings, collection string) (map[string]string, error) {
+	collection = strings.Trim(collection, "[]")
+	if collection == "" {
+		return nil, nil
+	}
+	key := datasourceCacheKey(pCtx, settings, collection)
+	if v, ok := d.fieldDescriptionsCache.Load(key); ok {
+		entry := v.(fieldDescriptionsEntry)
+		if time.Now().Before(entry.expires) {
+			return entry.descriptions, nil
+		}
+	}
+
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	descriptions := map[string]string{}
+	doc, err := client.Collection(fieldDescriptionsCollection).Doc(url.PathEscape(collection)).Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, err
+	}
+	if err == nil {
+		for name, value := range doc.Data() {
+			if description, ok := value.(string); ok {
+				descriptions[name] = description
+			}
+		}
+	}
+
+	d.fieldDescriptionsCache.Store(key, fieldDescriptionsEntry{
+		descriptions: descriptions,
+		expires:      time.Now().Add(fieldDescriptionsTTL),
+	})
+	return descriptions, nil
+}
+
+// applyFieldDescriptions sets the config description of every frame field
+// that has a matching entry in descriptions.
+func applyFieldDescriptions(frame *data.Frame, descriptions map[string]string) {
+	for _, field := range frame.Fields {
+		description, ok := descriptions[field.Name]
+		if !ok {
+			continue
+		}
+		if field.Config == nil {
+			field.Config = &data.FieldConfig{}
+		}
+		field.Config.Description = description
+	}
+}
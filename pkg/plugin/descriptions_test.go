@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyFieldDescriptions(t *testing.T) {
+	frame := data.NewFrame("response",
+		data.NewField("email", nil, []*string{nil}),
+		data.NewField("name", nil, []*string{nil}),
+	)
+	applyFieldDescriptions(frame, map[string]string{"email": "Primary contact address"})
+
+	require.Equal(t, "Primary contact address", frame.Fields[0].Config.Description)
+	require.Nil(t, frame.Fields[1].Config)
+}
+
+func TestQueryDataFieldDescriptions(t *testing.T) {
+	ctx := context.Background()
+	_, err := newFirestoreTestClient(ctx).Collection("_meta").Doc("users").Set(ctx, map[string]interface{}{
+		"email": "Primary contact address",
+	})
+	require.NoError(t, err)
+
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+
+	ds := Datasource{}
+	resp, err := ds.QueryData(ctx, &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"query": "select email, username from users", "loadFieldDescriptions": true}`)},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+
+	email, _ := resp.Responses["A"].Frames[0].FieldByName("email")
+	require.NotNil(t, email)
+	require.Equal(t, "Primary contact address", email.Config.Description)
+}
+
+func TestFieldDescriptionsCacheKey(t *testing.T) {
+	ds := &Datasource{}
+	defer ds.Dispose()
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds1"}}
+	settings := FirestoreSettings{ProjectId: "test"}
+	ds.fieldDescriptionsCache.Store(datasourceCacheKey(pCtx, settings, "users"), fieldDescriptionsEntry{
+		descriptions: map[string]string{"email": "cached"},
+		expires:      time.Now().Add(time.Minute),
+	})
+
+	descriptions, err := ds.fieldDescriptions(context.Background(), pCtx, settings, "users")
+	require.NoError(t, err)
+	require.Equal(t, "cached", descriptions["email"])
+
+	descriptions, err = ds.fieldDescriptions(context.Background(), pCtx, settings, "")
+	require.NoError(t, err)
+	require.Empty(t, descriptions)
+
+	other := FirestoreSettings{ProjectId: "test", DatabaseName: "other"}
+	require.NotEqual(t, datasourceCacheKey(pCtx, settings, "users"), datasourceCacheKey(pCtx, other, "users"))
+	otherDatasource := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds2"}}
+	require.NotEqual(t, datasourceCacheKey(pCtx, settings, "users"), datasourceCacheKey(otherDatasource, settings, "users"))
+}
+
+func TestQueryDataSubcollectionFieldDescriptions(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	_, err := client.Collection("_meta").Doc("desc_users%2Fabc%2Forders").Set(ctx, map[string]interface{}{
+		"total": "Order total",
+	})
+	require.NoError(t, err)
+	_, err = client.Doc("desc_users/abc/orders/o1").Set(ctx, map[string]interface{}{"total": 10})
+	require.NoError(t, err)
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"},
+		`{"query": "select total from desc_users/abc/orders", "loadFieldDescriptions": true}`)
+	require.NoError(t, resp.Error)
+	total, _ := resp.Frames[0].FieldByName("total")
+	require.Equal(t, "Order total", total.Config.Description)
+}
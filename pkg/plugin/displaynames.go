@@ -0,0 +1,18 @@
+package plugin
+
+import "github.com/grafana/grafana-plugin-sdk-go/data"
+
+// applyDisplayNames sets the display name of the fields of frame named in
+// names, leaving their name untouched for transformations.
+func applyDisplayNames(frame *data.Frame, names map[string]string) {
+	for _, field := range frame.Fields {
+		displayName, ok := names[field.Name]
+		if !ok {
+			continue
+		}
+		if field.Config == nil {
+			field.Config = &data.FieldConfig{}
+		}
+		field.Config.DisplayName = displayName
+	}
+}
@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDisplayNames(t *testing.T) {
+	frame := data.NewFrame("response",
+		data.NewField("uid", nil, []string{"u1"}),
+		data.NewField("evt_tp", nil, []string{"login"}).SetConfig(&data.FieldConfig{Unit: "none"}),
+		data.NewField("other", nil, []string{"x"}),
+	)
+	applyDisplayNames(frame, map[string]string{"uid": "User ID", "evt_tp": "Event Type", "missing": "Missing"})
+
+	require.Equal(t, "uid", frame.Fields[0].Name)
+	require.Equal(t, "User ID", frame.Fields[0].Config.DisplayName)
+	require.Equal(t, "Event Type", frame.Fields[1].Config.DisplayName)
+	require.Equal(t, "none", frame.Fields[1].Config.Unit)
+	require.Nil(t, frame.Fields[2].Config)
+}
+
+func TestRenameFieldsQuery(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"ts"},
+		Records: [][]interface{}{{int64(1)}},
+	}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select ts from events", "renameFields": {"ts": "Timestamp"}}`)
+	require.NoError(t, resp.Error)
+	field, _ := resp.Frames[0].FieldByName("ts")
+	require.Equal(t, "Timestamp", field.Config.DisplayName)
+}
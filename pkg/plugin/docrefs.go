@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// Document reference handling modes for
+// FirestoreQuery.DocumentReferenceHandling.
+const (
+	documentReferenceHandlingPath = "path"
+	documentReferenceHandlingID   = "id"
+	documentReferenceHandlingURL  = "url"
+)
+
+// handleDocumentRefs replaces the document references of result according
+// to mode: "path", the default, returns their full resource path, "id" their
+// document ID and "url" their Firebase console URL. It returns the columns
+// holding console URLs.
+func handleDocumentRefs(result *util.QueryResult, mode string) ([]string, error) {
+	switch mode {
+	case "", documentReferenceHandlingPath, documentReferenceHandlingID, documentReferenceHandlingURL:
+	default:
+		return nil, fmt.Errorf("unknown document reference handling %q", mode)
+	}
+
+	var urlColumns []string
+	for _, record := range result.Records {
+		for i, value := range record {
+			ref, ok := value.(*firestore.DocumentRef)
+			if !ok {
+				continue
+			}
+			if ref == nil {
+				record[i] = nil
+				continue
+			}
+			switch mode {
+			case documentReferenceHandlingID:
+				record[i] = ref.ID
+			case documentReferenceHandlingURL:
+				record[i] = consoleURL(ref.Path)
+				if i < len(result.Columns) && !containsString(urlColumns, result.Columns[i]) {
+					urlColumns = append(urlColumns, result.Columns[i])
+				}
+			default:
+				record[i] = ref.Path
+			}
+		}
+	}
+	return urlColumns, nil
+}
+
+// consoleURL returns the Firebase console URL of the document at path, e.g.
+// projects/p/databases/(default)/documents/users/abc.
+func consoleURL(path string) string {
+	parts := strings.SplitN(path, "/", 6)
+	if len(parts) < 6 || parts[0] != "projects" || parts[2] != "databases" || parts[4] != "documents" {
+		return path
+	}
+	project, database, document := parts[1], parts[3], parts[5]
+
+	url := "https://console.firebase.google.com/project/" + project + "/firestore/"
+	if database != "(default)" {
+		url += "databases/" + database + "/"
+	}
+	return url + "data/~2F" + strings.ReplaceAll(document, "/", "~2F")
+}
+
+// addConsoleLinks makes Grafana render the values of the given fields of
+// frame as links opening in a new tab.
+func addConsoleLinks(frame *data.Frame, names []string) {
+	for _, name := range names {
+		field, _ := frame.FieldByName(name)
+		if field == nil {
+			continue
+		}
+		if field.Config == nil {
+			field.Config = &data.FieldConfig{}
+		}
+		field.Config.Links = append(field.Config.Links, data.DataLink{
+			Title:       "Open in Firebase console",
+			URL:         "${__value.raw}",
+			TargetBlank: true,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func docRefResult(t *testing.T, database string) *util.QueryResult {
+	client, err := firestore.NewClientWithDatabase(context.Background(), "p", database)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return &util.QueryResult{
+		Columns: []string{"manager"},
+		Records: [][]interface{}{{client.Collection("users").Doc("abc")}, {nil}},
+	}
+}
+
+func TestDocumentReferenceHandling(t *testing.T) {
+	t.Setenv(emulatorHostEnvVar, "localhost:8765")
+	for mode, expected := range map[string]string{
+		"":                            "projects/p/databases/(default)/documents/users/abc",
+		documentReferenceHandlingPath: "projects/p/databases/(default)/documents/users/abc",
+		documentReferenceHandlingID:   "abc",
+		documentReferenceHandlingURL:  "https://console.firebase.google.com/project/p/firestore/data/~2Fusers~2Fabc",
+	} {
+		result := docRefResult(t, "(default)")
+		urlColumns, err := handleDocumentRefs(result, mode)
+		require.NoError(t, err)
+		require.Equal(t, expected, result.Records[0][0], mode)
+		require.Nil(t, result.Records[1][0])
+		require.Equal(t, mode == documentReferenceHandlingURL, len(urlColumns) == 1)
+	}
+
+	_, err := handleDocumentRefs(docRefResult(t, "(default)"), "name")
+	require.Error(t, err)
+}
+
+func TestDocumentReferenceURLs(t *testing.T) {
+	t.Setenv(emulatorHostEnvVar, "localhost:8765")
+	result := docRefResult(t, "analytics")
+	urlColumns, err := handleDocumentRefs(result, documentReferenceHandlingURL)
+	require.NoError(t, err)
+	require.Equal(t, "https://console.firebase.google.com/project/p/firestore/databases/analytics/data/~2Fusers~2Fabc", result.Records[0][0])
+
+	frame := newResponseFrame(result, false)
+	addConsoleLinks(frame, urlColumns)
+	field, _ := frame.FieldByName("manager")
+	require.Len(t, field.Config.Links, 1)
+	require.True(t, field.Config.Links[0].TargetBlank)
+}
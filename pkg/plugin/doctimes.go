@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	createTimeField = "__create_time"
+	updateTimeField = "__update_time"
+	// maxGetAllRefs is the maximum number of documents Firestore reads in a
+	// single GetAll call.
+	maxGetAllRefs = 500
+)
+
+// addDocumentTimes appends the __create_time and __update_time fields of the
+// documents of collection identified by the document ID field of frame.
+// Rows without a document ID, which is only set when the query selects
+// __name__, or whose document no longer exists get nil times.
+func (d *Datasource) addDocumentTimes(ctx context.Context, pCtx backend.PluginContext, frame *data.Frame, collection string) error {
+	if collection == "" || strings.HasPrefix(collection, "[") {
+		return errors.New("IncludeDocumentMetadata requires a query on a single collection")
+	}
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ref, err := collectionRef(client, collection)
+	if err != nil {
+		return err
+	}
+
+	var refs []*firestore.DocumentRef
+	var rows []int
+	ids := frame.Fields[0]
+	for i := 0; i < ids.Len(); i++ {
+		if id, ok := ids.At(i).(*string); ok && id != nil && *id != "" {
+			refs = append(refs, ref.Doc(*id))
+			rows = append(rows, i)
+		}
+	}
+
+	createTimes := make([]*time.Time, frame.Rows())
+	updateTimes := make([]*time.Time, frame.Rows())
+	for _, batch := range chunkRefs(refs, maxGetAllRefs) {
+		snapshots, err := client.GetAll(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			if !snapshot.Exists() {
+				rows = rows[1:]
+				continue
+			}
+			createTime, updateTime := snapshot.CreateTime, snapshot.UpdateTime
+			createTimes[rows[0]] = &createTime
+			updateTimes[rows[0]] = &updateTime
+			rows = rows[1:]
+		}
+	}
+
+	frame.Fields = append(frame.Fields,
+		data.NewField(createTimeField, nil, createTimes),
+		data.NewField(updateTimeField, nil, updateTimes),
+	)
+	return nil
+}
+
+// chunkRefs splits refs into batches of at most size references.
+func chunkRefs(refs []*firestore.DocumentRef, size int) [][]*firestore.DocumentRef {
+	var chunks [][]*firestore.DocumentRef
+	for len(refs) > size {
+		chunks = append(chunks, refs[:size])
+		refs = refs[size:]
+	}
+	if len(refs) > 0 {
+		chunks = append(chunks, refs)
+	}
+	return chunks
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkRefs(t *testing.T) {
+	refs := make([]*firestore.DocumentRef, 1001)
+	chunks := chunkRefs(refs, maxGetAllRefs)
+	require.Len(t, chunks, 3)
+	require.Len(t, chunks[0], 500)
+	require.Len(t, chunks[2], 1)
+	require.Empty(t, chunkRefs(nil, maxGetAllRefs))
+}
+
+func TestQueryDataDocumentMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for i := 0; i < 3; i++ {
+		_, err := client.Collection("metadata_users").Doc(fmt.Sprintf("u%d", i)).Set(ctx, map[string]interface{}{"n": i})
+		require.NoError(t, err)
+	}
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"},
+		`{"query": "select __name__, n from metadata_users", "includeDocumentMetadata": true}`)
+	require.NoError(t, resp.Error)
+	frame := resp.Frames[0]
+	created, _ := frame.FieldByName(createTimeField)
+	updated, _ := frame.FieldByName(updateTimeField)
+	require.NotNil(t, created)
+	require.NotNil(t, updated)
+	for i := 0; i < frame.Rows(); i++ {
+		require.WithinDuration(t, time.Now(), *created.At(i).(*time.Time), time.Minute)
+		require.False(t, updated.At(i).(*time.Time).Before(*created.At(i).(*time.Time)))
+	}
+}
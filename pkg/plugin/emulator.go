@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"os"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// emulatorHostEnvVar is the standard variable pointing the Firestore SDK at
+// an emulator, e.g. in Docker Compose test setups.
+const emulatorHostEnvVar = "FIRESTORE_EMULATOR_HOST"
+
+// emulatorHostFromEnvironment returns the Firestore emulator address set in
+// the environment, if any. The SDK then connects to the emulator without TLS
+// or credentials, so the credential and TLS settings are ignored.
+func emulatorHostFromEnvironment() string {
+	host := os.Getenv(emulatorHostEnvVar)
+	if host != "" {
+		log.DefaultLogger.Warn("Using the Firestore emulator, credentials and TLS settings are ignored", "host", host)
+	}
+	return host
+}
+
+// emulatorHost returns the Firestore emulator address of the datasource: its
+// EmulatorHost setting, or else the one set in the environment.
+func emulatorHost(settings FirestoreSettings) string {
+	if settings.EmulatorHost != "" {
+		return settings.EmulatorHost
+	}
+	return emulatorHostFromEnvironment()
+}
+
+// emulatorClientOptions returns the client options connecting to the
+// Firestore emulator at host, which serves plaintext gRPC without
+// authentication.
+func emulatorClientOptions(host string) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(host),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFirestoreClientEmulatorFromEnvironment(t *testing.T) {
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test", TLSCACertPEM: "not base64!"})
+	require.NoError(t, err)
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+		JSONData:                jsonSettings,
+		DecryptedSecureJSONData: map[string]string{"serviceAccount": "not json"},
+	}}
+
+	t.Setenv(emulatorHostEnvVar, "")
+	_, err = newFirestoreClient(context.Background(), pCtx)
+	require.Error(t, err)
+
+	// The emulator takes precedence over the credential and TLS settings
+	t.Setenv(emulatorHostEnvVar, "localhost:8765")
+	client, err := newFirestoreClient(context.Background(), pCtx)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+}
+
+func TestNewFirestoreClientEmulatorHostSetting(t *testing.T) {
+	t.Setenv(emulatorHostEnvVar, "")
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test", EmulatorHost: "localhost:8765", TLSCACertPEM: "not base64!"})
+	require.NoError(t, err)
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+		JSONData:                jsonSettings,
+		DecryptedSecureJSONData: map[string]string{"serviceAccount": "not json"},
+	}}
+
+	client, err := newFirestoreClient(context.Background(), pCtx)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+}
+
+func TestHealthMessageEmulatorHost(t *testing.T) {
+	result, _ := checkHealthWithPings(t, FirestoreSettings{EmulatorHost: "localhost:8765"}, []error{nil})
+	require.Equal(t, "Data source is working. Connected to Firestore emulator at localhost:8765", result.Message)
+
+	result, _ = checkHealthWithPings(t, FirestoreSettings{}, []error{nil})
+	require.Equal(t, "Data source is working", result.Message)
+}
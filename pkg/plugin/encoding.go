@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// checkFrameEncoding encodes the frames in the Arrow format used within the
+// plugin's protobuf responses to Grafana, and in JSON for comparison, logging
+// the size and duration of both at Debug level. Encoding the frames up front
+// turns a frame that cannot be encoded into an error of its query instead of
+// a failure of the whole response.
+func checkFrameEncoding(frames data.Frames) error {
+	for _, frame := range frames {
+		started := time.Now()
+		arrow, err := frame.MarshalArrow()
+		if err != nil {
+			return fmt.Errorf("frame %q cannot be encoded: %v", frame.Name, err)
+		}
+		arrowDuration := time.Since(started)
+
+		started = time.Now()
+		json, err := data.FrameToJSON(frame, data.IncludeAll)
+		jsonDuration := time.Since(started)
+		if err != nil {
+			log.DefaultLogger.Debug("Frame JSON encoding failed", "frame", frame.Name, "error", err)
+			continue
+		}
+		log.DefaultLogger.Debug("Frame encoding", "frame", frame.Name, "rows", frame.Rows(),
+			"arrowBytes", len(arrow), "arrowDuration", arrowDuration,
+			"jsonBytes", len(json), "jsonDuration", jsonDuration)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFrameEncoding(t *testing.T) {
+	names := []string{"alice", "bob"}
+	frame := data.NewFrame("response",
+		data.NewField("name", nil, []*string{&names[0], nil}),
+		data.NewField("score", nil, []float64{1.5, 2}),
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(60, 0)}),
+	)
+	require.NoError(t, checkFrameEncoding(data.Frames{frame}))
+
+	encoded, err := frame.MarshalArrow()
+	require.NoError(t, err)
+	decoded, err := data.UnmarshalArrowFrame(encoded)
+	require.NoError(t, err)
+	require.Equal(t, frame.Rows(), decoded.Rows())
+	for i, field := range frame.Fields {
+		require.Equal(t, field.Name, decoded.Fields[i].Name)
+		for row := 0; row < field.Len(); row++ {
+			require.Equal(t, field.At(row), decoded.Fields[i].At(row))
+		}
+	}
+}
+
+func TestCheckFrameEncodingInvalidFrame(t *testing.T) {
+	frame := data.NewFrame("response",
+		data.NewField("a", nil, []int64{1, 2}),
+		data.NewField("b", nil, []int64{1}),
+	)
+	require.Error(t, checkFrameEncoding(data.Frames{frame}))
+}
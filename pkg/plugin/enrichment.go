@@ -0,0 +1,179 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// maxEnrichmentCalls caps the number of distinct keys enriched per query.
+	maxEnrichmentCalls = 50
+	enrichmentTimeout  = 3 * time.Second
+	// maxEnrichmentResponseSize caps the size of an enrichment response body.
+	maxEnrichmentResponseSize = 1 << 20
+)
+
+// enrichmentClient does not follow redirects, which could lead outside of
+// the allowed enrichment URLs.
+var enrichmentClient = &http.Client{
+	Timeout: enrichmentTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// enrichFrame calls endpoint once per distinct value of keyField, passing it
+// as the "key" query parameter, and appends the fields of the JSON objects
+// returned as additional fields. Fields named like an existing field are
+// prefixed with "enrichment_". Failed calls and keys beyond
+// maxEnrichmentCalls are reported as warnings and leave their rows empty.
+// The endpoint must be under one of the allowed URLs, which are set by
+// admins in the datasource settings, so that query editors cannot make the
+// Grafana server call arbitrary hosts.
+func enrichFrame(ctx context.Context, frame *data.Frame, endpoint, keyField, method string, allowed []string) error {
+	base, err := url.Parse(endpoint)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return fmt.Errorf("invalid enrichment URL %q", endpoint)
+	}
+	if !enrichmentURLAllowed(base, allowed) {
+		return fmt.Errorf("enrichment URL %q is not allowed by the EnrichmentAllowedURLs setting", endpoint)
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && method != http.MethodPost {
+		return fmt.Errorf("unsupported enrichment method %q", method)
+	}
+	key, _ := frame.FieldByName(keyField)
+	if key == nil {
+		return fmt.Errorf("enrichment key field %q not found", keyField)
+	}
+
+	keys := make([]string, key.Len())
+	hasKey := make([]bool, key.Len())
+	enrichments := map[string]OrderedMap{}
+	var columns []string
+	skipped := 0
+	for row := range keys {
+		v, ok := key.ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		keys[row], hasKey[row] = stringifyValue(v), true
+		if _, done := enrichments[keys[row]]; done {
+			continue
+		}
+		if len(enrichments) >= maxEnrichmentCalls {
+			skipped++
+			continue
+		}
+
+		enrichment, err := fetchEnrichment(ctx, method, base, keys[row])
+		if err != nil {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Enrichment of %q failed: %v", keys[row], err),
+			})
+		}
+		enrichments[keys[row]] = enrichment
+		for _, column := range enrichment.Keys() {
+			if !containsString(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	if skipped > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Enrichment is limited to %d keys, %d keys were not enriched", maxEnrichmentCalls, skipped),
+		})
+	}
+
+	for _, column := range columns {
+		values := make([]*string, len(keys))
+		for row := range keys {
+			if !hasKey[row] {
+				continue
+			}
+			if v, ok := enrichments[keys[row]].Get(column); ok && v != nil {
+				s := stringifyValue(v)
+				values[row] = &s
+			}
+		}
+		name := column
+		if f, _ := frame.FieldByName(name); f != nil {
+			name = "enrichment_" + name
+		}
+		frame.Fields = append(frame.Fields, data.NewField(name, nil, values))
+	}
+	return nil
+}
+
+// enrichmentURLAllowed reports whether u has the scheme and host of one of
+// the allowed URLs and a path under its path. Paths with dot segments are
+// rejected, as the server may resolve them outside of the allowed path.
+func enrichmentURLAllowed(u *url.URL, allowed []string) bool {
+	if u.User != nil {
+		return false
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	for _, a := range allowed {
+		prefix, err := url.Parse(a)
+		if err != nil || prefix.Scheme != u.Scheme || !strings.EqualFold(prefix.Host, u.Host) {
+			continue
+		}
+		dir := strings.TrimSuffix(prefix.EscapedPath(), "/")
+		if path := u.EscapedPath(); path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchEnrichment returns the JSON object returned by the endpoint for key.
+func fetchEnrichment(ctx context.Context, method string, base *url.URL, key string) (OrderedMap, error) {
+	ctx, cancel := context.WithTimeout(ctx, enrichmentTimeout)
+	defer cancel()
+
+	u := *base
+	params := u.Query()
+	params.Set("key", key)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := enrichmentClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxEnrichmentResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	var enrichment OrderedMap
+	if err := json.Unmarshal(body, &enrichment); err != nil {
+		return nil, errors.New("response is not a JSON object")
+	}
+	return enrichment, nil
+}
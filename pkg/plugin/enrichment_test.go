@@ -0,0 +1,103 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrichFrame(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Query().Get("key") {
+		case "c1":
+			_, _ = w.Write([]byte(`{"tier": "gold", "name": "Acme", "seats": 12}`))
+		case "c2":
+			_, _ = w.Write([]byte(`{"tier": "silver"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	customers := []string{"c1", "c2", "c1", "c3"}
+	name := "order"
+	frame := data.NewFrame("response",
+		data.NewField("customer", nil, []*string{&customers[0], &customers[1], &customers[2], &customers[3], nil}),
+		data.NewField("name", nil, []*string{&name, &name, &name, &name, &name}),
+	)
+
+	require.NoError(t, enrichFrame(context.Background(), frame, server.URL+"/customers", "customer", "", []string{server.URL + "/customers"}))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	tier, _ := frame.FieldByName("tier")
+	require.NotNil(t, tier)
+	require.Equal(t, "gold", *tier.At(0).(*string))
+	require.Equal(t, "silver", *tier.At(1).(*string))
+	require.Equal(t, "gold", *tier.At(2).(*string))
+	require.Nil(t, tier.At(3))
+	require.Nil(t, tier.At(4))
+
+	// Fields colliding with existing ones are prefixed
+	enrichedName, _ := frame.FieldByName("enrichment_name")
+	require.NotNil(t, enrichedName)
+	require.Equal(t, "Acme", *enrichedName.At(0).(*string))
+	seats, _ := frame.FieldByName("seats")
+	require.Equal(t, "12", *seats.At(2).(*string))
+
+	// The failed call for c3 is reported
+	require.Len(t, frame.Meta.Notices, 1)
+	require.Contains(t, frame.Meta.Notices[0].Text, `"c3"`)
+}
+
+func TestEnrichFrameErrors(t *testing.T) {
+	frame := data.NewFrame("response", data.NewField("customer", nil, []*string{}))
+	allowed := []string{"http://localhost"}
+	require.Error(t, enrichFrame(context.Background(), frame, "file:///etc/passwd", "customer", "", allowed))
+	require.Error(t, enrichFrame(context.Background(), frame, "http://localhost", "missing", "", allowed))
+	require.Error(t, enrichFrame(context.Background(), frame, "http://localhost", "customer", "DELETE", allowed))
+	require.ErrorContains(t, enrichFrame(context.Background(), frame, "http://169.254.169.254/computeMetadata", "customer", "", allowed), "not allowed")
+	require.ErrorContains(t, enrichFrame(context.Background(), frame, "http://localhost", "customer", "", nil), "not allowed")
+}
+
+func TestEnrichmentURLAllowed(t *testing.T) {
+	allowed := []string{"https://api.example.com/customers/", "http://internal:8080"}
+	for endpoint, ok := range map[string]bool{
+		"https://api.example.com/customers":             true,
+		"https://api.example.com/customers/lookup":      true,
+		"https://API.example.com/customers/lookup?x=1":  true,
+		"https://api.example.com/customersecrets":       false,
+		"https://api.example.com/orders":                false,
+		"http://api.example.com/customers":              false,
+		"https://api.example.com.evil.com/customers":    false,
+		"https://user@api.example.com/customers":        false,
+		"http://internal:8080/anything":                 true,
+		"http://internal/anything":                      false,
+		"http://169.254.169.254/computeMetadata/v1/":    false,
+		"https://api.example.com/customers/../accounts": false,
+	} {
+		u, err := url.Parse(endpoint)
+		require.NoError(t, err)
+		require.Equal(t, ok, enrichmentURLAllowed(u, allowed), endpoint)
+	}
+}
+
+func TestEnrichFrameDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/computeMetadata/v1/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	customer := "c1"
+	frame := data.NewFrame("response", data.NewField("customer", nil, []*string{&customer}))
+	require.NoError(t, enrichFrame(context.Background(), frame, server.URL, "customer", "", []string{server.URL}))
+	require.Len(t, frame.Meta.Notices, 1)
+	require.Contains(t, frame.Meta.Notices[0].Text, "302")
+}
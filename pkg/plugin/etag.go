@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// frameETag hashes the fields and values of the frame. Its metadata, which
+// holds per-execution details such as the execution time, is left out.
+func frameETag(frame *data.Frame) (string, error) {
+	f := *frame
+	f.Meta = nil
+	b, err := data.FrameToJSON(&f, data.IncludeAll)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// tagFrame sets the "etag" custom metadata of the frame returned for a query
+// and, when the frame is the same as the one previously returned for the
+// query, the "unchanged" one. Queries are told apart by key, as every panel
+// has its own RefID "A".
+func (d *Datasource) tagFrame(key string, frame *data.Frame) error {
+	etag, err := frameETag(frame)
+	if err != nil {
+		return err
+	}
+	setCustomMeta(frame, "etag", etag)
+	if previous, ok := d.etags.Swap(key, etag); ok && previous == etag {
+		setCustomMeta(frame, "unchanged", "true")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryETag(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"status"}, Records: [][]interface{}{{"open"}}},
+		{Columns: []string{"status"}, Records: [][]interface{}{{"open"}}},
+		{Columns: []string{"status"}, Records: [][]interface{}{{"closed"}}},
+	}}
+	ds := newMockDatasource(mock)
+	settings := FirestoreSettings{ProjectId: "test", ETagSupport: true}
+	query := `{"query": "select status from jobs"}`
+
+	var etags []string
+	var unchanged []bool
+	for i := 0; i < 3; i++ {
+		resp := runQuery(t, ds, settings, query)
+		require.NoError(t, resp.Error)
+		custom := resp.Frames[0].Meta.Custom.(map[string]interface{})
+		etags = append(etags, custom["etag"].(string))
+		unchanged = append(unchanged, custom["unchanged"] == "true")
+	}
+
+	require.Equal(t, etags[0], etags[1])
+	require.NotEqual(t, etags[1], etags[2])
+	require.Equal(t, []bool{false, true, false}, unchanged)
+}
+
+func TestQueryETagPerQuery(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"status"}, Records: [][]interface{}{{"open"}}}
+	mock := &mockExecutor{results: []*util.QueryResult{result, result}}
+	ds := newMockDatasource(mock)
+	settings := FirestoreSettings{ProjectId: "test", ETagSupport: true}
+
+	// Both panels use RefID "A" and get the same frame, but the second one
+	// never rendered the first one's data
+	runQuery(t, ds, settings, `{"query": "select status from jobs"}`)
+	resp := runQuery(t, ds, settings, `{"query": "select status from jobs where status = 'open'"}`)
+	require.NoError(t, resp.Error)
+	require.Nil(t, resp.Frames[0].Meta.Custom.(map[string]interface{})["unchanged"])
+}
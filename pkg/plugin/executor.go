@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// clientExecutor runs FireQL statements with a cached Firestore client.
+// fireql opens and closes a Firestore client, and so a gRPC connection, for
+// every query; statements that selectStatement cannot express are still
+// passed to fireql. COUNT, SUM and AVG statements run as aggregation queries.
+type clientExecutor struct {
+	// client returns the Firestore client to run a statement with and a
+	// function releasing it once the statement completed.
+	client func() (*firestore.Client, func(), error)
+	// fallback is nil when fireql would not connect like client, in which
+	// case these statements are rejected.
+	fallback queryExecutor
+	// defaultLimit is the limit of statements without a LIMIT clause.
+	defaultLimit int
+}
+
+// contextExecutor is implemented by executors whose queries can be
+// cancelled through a context.
+type contextExecutor interface {
+	ExecuteContext(ctx context.Context, query string) (*util.QueryResult, error)
+}
+
+// Execute runs the query without binding it to a request: like with fireql,
+// background polling keeps reusing the executor after the request completes.
+func (e *clientExecutor) Execute(query string) (*util.QueryResult, error) {
+	return e.ExecuteContext(context.Background(), query)
+}
+
+// ExecuteContext runs the query, cancelling the Firestore calls of native
+// statements when ctx is done. Statements passed to fireql keep running.
+func (e *clientExecutor) ExecuteContext(ctx context.Context, query string) (*util.QueryResult, error) {
+	if stmt, ok := parseAggregationStatement(query); ok {
+		client, release, err := e.client()
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return stmt.run(ctx, client)
+	}
+
+	stmt, err := parseSelectStatement(query)
+	if err != nil {
+		if e.fallback == nil {
+			return nil, fmt.Errorf("unsupported statement with these connection settings: %v", err)
+		}
+		return e.fallback.Execute(query)
+	}
+	if stmt.Limit == 0 {
+		stmt.Limit = e.defaultLimit
+	}
+	client, release, err := e.client()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	q, err := stmt.firestoreQuery(client)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := q.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return stmt.result(docs), nil
+}
+
+// fireqlCompatible reports whether fireql, which builds its own client from
+// the project, service account and database only, connects to Firestore
+// like newFirestoreClient does with settings. Otherwise a fallback query
+// could reach production instead of the emulator, or bypass the configured
+// credentials and TLS options.
+func fireqlCompatible(settings FirestoreSettings) bool {
+	return settings.EmulatorHost == "" &&
+		settings.WorkloadIdentityConfigFile == "" &&
+		!settings.UseApplicationDefaultCredentials &&
+		settings.TLSCACertPEM == "" &&
+		!settings.TLSSkipVerify
+}
@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientExecutorFallback(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"n"}, Records: [][]interface{}{{int64(3)}}}}}
+	executor := &clientExecutor{fallback: mock}
+
+	result, err := executor.Execute("select max(age) from users")
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{{int64(3)}}, result.Records)
+	require.Equal(t, []string{"select max(age) from users"}, mock.queries)
+}
+
+func TestQueryDataClientReuse(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	_, err := client.Collection("reuse_users").Doc("u1").Set(ctx, map[string]interface{}{"name": "Leanne"})
+	require.NoError(t, err)
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	for i := 0; i < 3; i++ {
+		resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, `{"query": "select name from reuse_users"}`)
+		require.NoError(t, resp.Error)
+		require.Equal(t, 1, resp.Frames[0].Rows())
+	}
+	require.Equal(t, 1, ds.clients.lru.Len())
+}
+
+// BenchmarkQueryData measures a query against the emulator, including the
+// cost of obtaining a Firestore client. Run with -bench QueryData.
+func BenchmarkQueryData(b *testing.B) {
+	ds := &Datasource{}
+	defer ds.Dispose()
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"ProjectId": "test"}`)},
+		},
+		Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"query": "select name from reuse_users"}`)}},
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := ds.QueryData(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestClientExecutorWithoutFallback(t *testing.T) {
+	executor := &clientExecutor{}
+	_, err := executor.Execute("select max(age) from users")
+	require.ErrorContains(t, err, "unsupported statement")
+}
+
+func TestFireqlCompatible(t *testing.T) {
+	require.True(t, fireqlCompatible(FirestoreSettings{ProjectId: "test", ServiceAccounts: []WeightedServiceAccount{{Weight: 1}}}))
+	for _, settings := range []FirestoreSettings{
+		{EmulatorHost: "localhost:8080"},
+		{WorkloadIdentityConfigFile: "/etc/wif.json"},
+		{UseApplicationDefaultCredentials: true},
+		{TLSCACertPEM: "cGVt"},
+		{TLSSkipVerify: true},
+	} {
+		require.False(t, fireqlCompatible(settings), "%+v", settings)
+	}
+}
+
+func TestExecutorFallbackFollowsSettings(t *testing.T) {
+	ds := &Datasource{}
+	defer ds.Dispose()
+	pCtx := func(settings FirestoreSettings) backend.PluginContext {
+		jsonSettings, err := json.Marshal(settings)
+		require.NoError(t, err)
+		return backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings}}
+	}
+
+	settings := FirestoreSettings{ProjectId: "test"}
+	executor, err := ds.executor(pCtx(settings), settings)
+	require.NoError(t, err)
+	require.NotNil(t, executor.(*clientExecutor).fallback)
+
+	settings = FirestoreSettings{ProjectId: "test", EmulatorHost: "localhost:8765"}
+	executor, err = ds.executor(pCtx(settings), settings)
+	require.NoError(t, err)
+	require.Nil(t, executor.(*clientExecutor).fallback)
+}
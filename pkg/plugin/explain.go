@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"google.golang.org/api/iterator"
+)
+
+const explainFrameName = "explain"
+
+// explainQuery runs the query with Firestore query explain enabled and returns
+// the planner metrics as a frame. When analyze is false only the query plan is
+// requested and no documents are read. A query without LIMIT clause is
+// limited to defaultLimit, like when it is executed.
+func explainQuery(ctx context.Context, client *firestore.Client, query string, analyze bool, defaultLimit int) (*data.Frame, error) {
+	stmt, err := parseSelectStatement(query)
+	if err != nil {
+		return nil, err
+	}
+	_, metrics, err := explainStatement(ctx, client, stmt, analyze, defaultLimit)
+	if err != nil {
+		return nil, err
+	}
+	return newExplainFrame(metrics), nil
+}
+
+// explainAnalyzeQuery executes the statement of an Explain query once, with
+// analysis enabled, returning both its result and its explain frame, so that
+// the query is not run a second time to collect the metrics.
+func (d *Datasource) explainAnalyzeQuery(ctx context.Context, pCtx backend.PluginContext, stmt *selectStatement, settings FirestoreSettings) (*util.QueryResult, *data.Frame, error) {
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	docs, metrics, err := explainStatement(ctx, client, stmt, true, queryLimit(settings))
+	if err != nil {
+		return nil, nil, err
+	}
+	return stmt.result(docs), newExplainFrame(metrics), nil
+}
+
+// explainStatement runs stmt with query explain enabled, returning the
+// documents read, if any, and the explain metrics.
+func explainStatement(ctx context.Context, client *firestore.Client, stmt *selectStatement, analyze bool, defaultLimit int) ([]*firestore.DocumentSnapshot, *firestore.ExplainMetrics, error) {
+	if stmt.Limit == 0 {
+		stmt.Limit = defaultLimit
+	}
+	q, err := stmt.firestoreQuery(client)
+	if err != nil {
+		return nil, nil, err
+	}
+	it := q.
+		WithRunOptions(firestore.ExplainOptions{Analyze: analyze}).
+		Documents(ctx)
+	defer it.Stop()
+	var docs []*firestore.DocumentSnapshot
+	for {
+		doc, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	metrics, err := it.ExplainMetrics()
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, metrics, nil
+}
+
+// newExplainFrame flattens explain metrics into metric_name/metric_value rows.
+func newExplainFrame(metrics *firestore.ExplainMetrics) *data.Frame {
+	var names, values []string
+	add := func(name, value string) {
+		names = append(names, name)
+		values = append(values, value)
+	}
+	addJSON := func(name string, v interface{}) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			add(name, err.Error())
+			return
+		}
+		add(name, string(b))
+	}
+
+	if metrics != nil && metrics.PlanSummary != nil {
+		addJSON("indexes_used", metrics.PlanSummary.IndexesUsed)
+	}
+	if metrics != nil && metrics.ExecutionStats != nil {
+		stats := metrics.ExecutionStats
+		add("results_returned", strconv.FormatInt(stats.ResultsReturned, 10))
+		add("read_operations", strconv.FormatInt(stats.ReadOperations, 10))
+		if stats.ExecutionDuration != nil {
+			add("execution_duration", stats.ExecutionDuration.String())
+		}
+		if stats.DebugStats != nil {
+			addJSON("debug_stats", stats.DebugStats)
+		}
+	}
+
+	return data.NewFrame(explainFrameName,
+		data.NewField("metric_name", nil, names),
+		data.NewField("metric_value", nil, values),
+	)
+}
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExplainFrame(t *testing.T) {
+	duration := 15 * time.Millisecond
+	frame := newExplainFrame(&firestore.ExplainMetrics{
+		PlanSummary: &firestore.PlanSummary{
+			IndexesUsed: []*map[string]any{{"query_scope": "Collection", "properties": "(id ASC, __name__ ASC)"}},
+		},
+		ExecutionStats: &firestore.ExecutionStats{
+			ResultsReturned:   5,
+			ReadOperations:    5,
+			ExecutionDuration: &duration,
+		},
+	})
+
+	require.Equal(t, "explain", frame.Name)
+	require.Equal(t, "metric_name", frame.Fields[0].Name)
+	require.Equal(t, "metric_value", frame.Fields[1].Name)
+	require.Equal(t, 4, frame.Rows())
+	require.Equal(t, "indexes_used", frame.Fields[0].At(0))
+	require.Equal(t, "results_returned", frame.Fields[0].At(1))
+	require.Equal(t, "5", frame.Fields[1].At(1))
+	require.Equal(t, "15ms", frame.Fields[1].At(3))
+}
+
+func TestQueryDataExplain(t *testing.T) {
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+
+	ds := Datasource{}
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: []byte(`{"query": "select * from users", "explain": true}`)},
+			{RefID: "B", JSON: []byte(`{"query": "select * from users", "explain": false}`)},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, resp.Responses["A"].Error)
+	require.Len(t, resp.Responses["A"].Frames, 2)
+	require.Equal(t, "explain", resp.Responses["A"].Frames[1].Name)
+	require.NoError(t, resp.Responses["B"].Error)
+	require.Len(t, resp.Responses["B"].Frames, 1)
+}
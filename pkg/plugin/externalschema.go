@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	externalSchemaTimeout = 5 * time.Second
+	externalSchemaTTL     = 5 * time.Minute
+	// maxExternalSchemaSize caps the size of an external schema response body.
+	maxExternalSchemaSize = 1 << 20
+)
+
+var externalSchemaClient = &http.Client{Timeout: externalSchemaTimeout}
+
+type externalSchemaEntry struct {
+	fields  []schemaField
+	expires time.Time
+}
+
+// externalSchema returns the fields of collection defined by the schema
+// endpoint, which is called with the "collection" query parameter and must
+// answer in the GET /schema format. Schemas are cached for externalSchemaTTL.
+func (d *Datasource) externalSchema(ctx context.Context, endpoint, collection string) ([]schemaField, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid ExternalSchemaURL %q", endpoint)
+	}
+	params := u.Query()
+	params.Set("collection", collection)
+	u.RawQuery = params.Encode()
+	key := u.String()
+
+	if v, ok := d.externalSchemaCache.Load(key); ok {
+		entry := v.(externalSchemaEntry)
+		if time.Now().Before(entry.expires) {
+			return entry.fields, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, externalSchemaTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := externalSchemaClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("external schema: unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalSchemaSize))
+	if err != nil {
+		return nil, err
+	}
+	var schema schemaResponse
+	if err := json.Unmarshal(body, &schema); err != nil {
+		return nil, fmt.Errorf("external schema: invalid response: %v", err)
+	}
+	if schema.Fields == nil {
+		schema.Fields = []schemaField{}
+	}
+
+	d.externalSchemaCache.Store(key, externalSchemaEntry{
+		fields:  schema.Fields,
+		expires: time.Now().Add(externalSchemaTTL),
+	})
+	return schema.Fields, nil
+}
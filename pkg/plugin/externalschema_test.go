@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallResourceSchemaExternalSchemaURL(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		require.Equal(t, "users", r.URL.Query().Get("collection"))
+		require.Equal(t, "v1", r.URL.Query().Get("version"))
+		_, _ = w.Write([]byte(`{"fields": [{"name": "age", "type": "int64"}, {"name": "name", "type": "string"}]}`))
+	}))
+	defer server.Close()
+
+	// No Firestore client can be created for this project, so the schema
+	// must come from the external endpoint.
+	ds := &Datasource{}
+	settings := FirestoreSettings{ExternalSchemaURL: server.URL + "/schema?version=v1", AllowedProjectIDs: []string{"other"}}
+
+	for i := 0; i < 2; i++ {
+		resp := callResource(t, ds, settings, "Viewer", "GET", "schema?collection=users", "")
+		require.Equal(t, 200, resp.Status, string(resp.Body))
+		require.JSONEq(t, `{"fields": [{"name": "age", "type": "int64"}, {"name": "name", "type": "string"}]}`, string(resp.Body))
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCallResourceSchemaExternalSchemaURLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	resp := callResource(t, &Datasource{}, FirestoreSettings{ExternalSchemaURL: server.URL}, "Viewer", "GET", "schema?collection=users", "")
+	require.Equal(t, 502, resp.Status)
+
+	resp = callResource(t, &Datasource{}, FirestoreSettings{ExternalSchemaURL: "ftp://schemas"}, "Viewer", "GET", "schema?collection=users", "")
+	require.Equal(t, 502, resp.Status)
+}
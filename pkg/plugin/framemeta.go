@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// setCustomMeta sets key in the custom metadata of the frame.
+func setCustomMeta(frame *data.Frame, key string, value interface{}) {
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	custom, ok := frame.Meta.Custom.(map[string]interface{})
+	if !ok {
+		custom = map[string]interface{}{}
+		frame.Meta.Custom = custom
+	}
+	custom[key] = value
+}
+
+// validateFrameMetadata rejects user metadata keys using the reserved prefix.
+func validateFrameMetadata(metadata map[string]string) error {
+	for key := range metadata {
+		if strings.HasPrefix(key, reservedFieldPrefix) {
+			return fmt.Errorf("frame metadata key %q must not start with %q", key, reservedFieldPrefix)
+		}
+	}
+	return nil
+}
+
+// applyFrameMetadata merges the user metadata into the custom metadata of the frame.
+func applyFrameMetadata(frame *data.Frame, metadata map[string]string) {
+	for key, value := range metadata {
+		setCustomMeta(frame, key, value)
+	}
+}
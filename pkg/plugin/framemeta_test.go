@@ -0,0 +1,30 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFrameMetadata(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}, Records: [][]interface{}{{"1"}}}}}
+
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from users", "frameMetadata": {"team": "payments", "panel": "latency"}}`)
+	require.NoError(t, resp.Error)
+
+	custom := resp.Frames[0].Meta.Custom.(map[string]interface{})
+	require.Equal(t, "payments", custom["team"])
+	require.Equal(t, "latency", custom["panel"])
+	require.Contains(t, custom, "executionTimeMs")
+}
+
+func TestQueryFrameMetadataReservedKey(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{}}}
+
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from users", "frameMetadata": {"__internal": "x"}}`)
+	require.Error(t, resp.Error)
+	require.Zero(t, mock.calls())
+}
@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+const (
+	distanceField = "__distance_km"
+
+	earthRadiusKm = 6371.0
+)
+
+// GeoFilter keeps only documents whose GeoPoint Field lies within RadiusKm
+// of the center point. Filtering happens client-side after the query ran.
+type GeoFilter struct {
+	Field     string
+	CenterLat float64
+	CenterLon float64
+	RadiusKm  float64
+}
+
+// filterByDistance drops the records whose GeoPoint is missing or outside
+// the filter radius. It returns the remaining records along with the distance
+// of each one to the center.
+func filterByDistance(result *util.QueryResult, filter *GeoFilter) (*util.QueryResult, []*float64, error) {
+	colIdx := -1
+	for i, column := range result.Columns {
+		if column == filter.Field {
+			colIdx = i
+			break
+		}
+	}
+	if colIdx == -1 {
+		return nil, nil, fmt.Errorf("geo filter: unknown field %q", filter.Field)
+	}
+
+	filtered := &util.QueryResult{Columns: result.Columns, Records: [][]interface{}{}}
+	var distances []*float64
+	for _, record := range result.Records {
+		if colIdx >= len(record) {
+			continue
+		}
+		point, ok := record[colIdx].(*latlng.LatLng)
+		if !ok || point == nil {
+			continue
+		}
+		distance := haversineKm(filter.CenterLat, filter.CenterLon, point.GetLatitude(), point.GetLongitude())
+		if distance > filter.RadiusKm {
+			continue
+		}
+		filtered.Records = append(filtered.Records, record)
+		distances = append(distances, &distance)
+	}
+	return filtered, distances, nil
+}
+
+// haversineKm returns the great-circle distance between two points in kilometers.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+func TestFilterByDistance(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"name", "location"},
+		Records: [][]interface{}{
+			{"Berlin", &latlng.LatLng{Latitude: 52.520, Longitude: 13.405}},
+			{"Hamburg", &latlng.LatLng{Latitude: 53.551, Longitude: 9.994}},
+			{"Potsdam", &latlng.LatLng{Latitude: 52.391, Longitude: 13.064}},
+			{"Munich", &latlng.LatLng{Latitude: 48.137, Longitude: 11.576}},
+			{"Oranienburg", &latlng.LatLng{Latitude: 52.754, Longitude: 13.236}},
+		},
+	}
+
+	filtered, distances, err := filterByDistance(result, &GeoFilter{
+		Field:     "location",
+		CenterLat: 52.520,
+		CenterLon: 13.405,
+		RadiusKm:  50,
+	})
+	require.NoError(t, err)
+	require.Len(t, filtered.Records, 3)
+	require.Len(t, distances, 3)
+	require.Equal(t, "Berlin", filtered.Records[0][0])
+	require.Equal(t, "Potsdam", filtered.Records[1][0])
+	require.Equal(t, "Oranienburg", filtered.Records[2][0])
+	require.InDelta(t, 0, *distances[0], 0.001)
+	require.InDelta(t, 27, *distances[1], 1)
+
+	frame := newResponseFrame(filtered, false)
+	require.Equal(t, 3, frame.Rows())
+}
+
+func TestFilterByDistanceUnknownField(t *testing.T) {
+	_, _, err := filterByDistance(&util.QueryResult{Columns: []string{"name"}}, &GeoFilter{Field: "location"})
+	require.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+// GeoPoint handling modes for FirestoreQuery.GeoPointHandling.
+const (
+	geoPointHandlingSplit = "split"
+	geoPointHandlingWKT   = "wkt"
+	geoPointHandlingJSON  = "json"
+)
+
+// handleGeoPoints replaces the GeoPoint values of result according to mode.
+// With "split", the default, each column holding GeoPoints is replaced by
+// "<column>.latitude" and "<column>.longitude" columns. "wkt" formats them as
+// "POINT(lon lat)" and "json" as {"lat":..,"lng":..}.
+func handleGeoPoints(result *util.QueryResult, mode string) error {
+	switch mode {
+	case "", geoPointHandlingSplit:
+		splitGeoPoints(result)
+		return nil
+	case geoPointHandlingWKT, geoPointHandlingJSON:
+	default:
+		return fmt.Errorf("unknown GeoPoint handling %q", mode)
+	}
+
+	for _, record := range result.Records {
+		for i, value := range record {
+			point, ok := value.(*latlng.LatLng)
+			if !ok {
+				continue
+			}
+			if point == nil {
+				record[i] = nil
+				continue
+			}
+			if mode == geoPointHandlingWKT {
+				record[i] = "POINT(" + formatCoordinate(point.GetLongitude()) + " " + formatCoordinate(point.GetLatitude()) + ")"
+				continue
+			}
+			b, err := json.Marshal(map[string]float64{"lat": point.GetLatitude(), "lng": point.GetLongitude()})
+			if err != nil {
+				return err
+			}
+			record[i] = string(b)
+		}
+	}
+	return nil
+}
+
+func formatCoordinate(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// splitGeoPoints replaces each column holding GeoPoints with its latitude
+// and longitude columns. Values other than GeoPoints in such a column are
+// dropped.
+func splitGeoPoints(result *util.QueryResult) {
+	points := make([]bool, len(result.Columns))
+	split := false
+	for _, record := range result.Records {
+		for i, value := range record {
+			if point, ok := value.(*latlng.LatLng); ok && point != nil && i < len(points) {
+				points[i] = true
+				split = true
+			}
+		}
+	}
+	if !split {
+		return
+	}
+
+	var columns []string
+	for i, name := range result.Columns {
+		if points[i] {
+			columns = append(columns, name+".latitude", name+".longitude")
+		} else {
+			columns = append(columns, name)
+		}
+	}
+	for r, record := range result.Records {
+		values := make([]interface{}, 0, len(columns))
+		for i := range result.Columns {
+			var value interface{}
+			if i < len(record) {
+				value = record[i]
+			}
+			if !points[i] {
+				values = append(values, value)
+				continue
+			}
+			if point, ok := value.(*latlng.LatLng); ok && point != nil {
+				values = append(values, point.GetLatitude(), point.GetLongitude())
+			} else {
+				values = append(values, nil, nil)
+			}
+		}
+		result.Records[r] = values
+	}
+	result.Columns = columns
+}
@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+func geoPointResult() *util.QueryResult {
+	return &util.QueryResult{
+		Columns: []string{"name", "location"},
+		Records: [][]interface{}{
+			{"Munich", &latlng.LatLng{Latitude: 48.137, Longitude: 11.576}},
+			{"Nowhere", nil},
+		},
+	}
+}
+
+func TestGeoPointHandlingSplit(t *testing.T) {
+	result := geoPointResult()
+	require.NoError(t, handleGeoPoints(result, ""))
+	require.Equal(t, []string{"name", "location.latitude", "location.longitude"}, result.Columns)
+	require.Equal(t, [][]interface{}{{"Munich", 48.137, 11.576}, {"Nowhere", nil, nil}}, result.Records)
+
+	frame := newResponseFrame(result, false)
+	field, _ := frame.FieldByName("location.latitude")
+	require.Equal(t, 48.137, *field.At(0).(*float64))
+}
+
+func TestGeoPointHandlingWKT(t *testing.T) {
+	result := geoPointResult()
+	require.NoError(t, handleGeoPoints(result, geoPointHandlingWKT))
+	require.Equal(t, []string{"name", "location"}, result.Columns)
+	require.Equal(t, "POINT(11.576 48.137)", result.Records[0][1])
+	require.Nil(t, result.Records[1][1])
+}
+
+func TestGeoPointHandlingJSON(t *testing.T) {
+	result := geoPointResult()
+	require.NoError(t, handleGeoPoints(result, geoPointHandlingJSON))
+	require.Equal(t, `{"lat":48.137,"lng":11.576}`, result.Records[0][1])
+}
+
+func TestGeoPointHandlingUnknown(t *testing.T) {
+	require.Error(t, handleGeoPoints(geoPointResult(), "geojson"))
+}
@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// maxGroups is the maximum number of groups a GroupBy query may return.
+const maxGroups = 1000
+
+// AggregationSpec is an aggregation computed for every group of a GroupBy
+// query. Function is one of "sum", "avg", "min", "max" or "count".
+type AggregationSpec struct {
+	Field    string
+	Function string
+	// Alias names the result column, "<function>_<field>" by default.
+	Alias string
+}
+
+// column returns the name of the result column of the aggregation.
+func (a AggregationSpec) column() string {
+	switch {
+	case a.Alias != "":
+		return a.Alias
+	case a.Field == "" || a.Field == "*":
+		return a.Function
+	}
+	return a.Function + "_" + a.Field
+}
+
+// groupRecords replaces the records of result with one record per distinct
+// value of field, in order of first appearance, holding that value followed
+// by the aggregations. Nil values are ignored by every aggregation, and
+// "count" of the "*" field counts the records of the group.
+func groupRecords(result *util.QueryResult, field string, aggregations []AggregationSpec) error {
+	keyCol := resultColumn(result, field)
+	if keyCol < 0 {
+		return fmt.Errorf("group by field %q not found", field)
+	}
+	cols := make([]int, len(aggregations))
+	columns := []string{field}
+	for i, a := range aggregations {
+		switch a.Function {
+		case "sum", "avg", "min", "max", "count":
+		default:
+			return fmt.Errorf("unsupported aggregation %q", a.Function)
+		}
+		cols[i] = -1
+		if a.Function != "count" || a.Field != "" && a.Field != "*" {
+			if cols[i] = resultColumn(result, a.Field); cols[i] < 0 {
+				return fmt.Errorf("aggregation field %q not found", a.Field)
+			}
+		}
+		columns = append(columns, a.column())
+	}
+
+	groups := groupRows(len(result.Records), func(row int) interface{} {
+		return recordValue(result.Records[row], keyCol)
+	})
+	if len(groups) > maxGroups {
+		return fmt.Errorf("group by %q returns more than %d groups", field, maxGroups)
+	}
+
+	records := make([][]interface{}, len(groups))
+	for i, group := range groups {
+		record := []interface{}{recordValue(result.Records[group[0]], keyCol)}
+		for j, a := range aggregations {
+			value, err := aggregateGroup(result.Records, group, cols[j], a)
+			if err != nil {
+				return err
+			}
+			record = append(record, value)
+		}
+		records[i] = record
+	}
+	result.Columns = columns
+	result.Records = records
+	return nil
+}
+
+// aggregateGroup computes the aggregation of column col of the given rows of
+// records. It returns nil when the group has no value to aggregate.
+func aggregateGroup(records [][]interface{}, rows []int, col int, a AggregationSpec) (interface{}, error) {
+	if a.Function == "count" {
+		n := int64(0)
+		for _, row := range rows {
+			if col < 0 || recordValue(records[row], col) != nil {
+				n++
+			}
+		}
+		return n, nil
+	}
+
+	value, err := aggregateFloats(rows, func(row int) (*float64, error) {
+		v := recordValue(records[row], col)
+		if v == nil {
+			return nil, nil
+		}
+		f, ok := toFloat64(v)
+		if !ok {
+			return nil, fmt.Errorf("cannot %s non-numeric value %v of field %q", a.Function, v, a.Field)
+		}
+		return &f, nil
+	}, a.Function)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return *value, nil
+}
+
+func recordValue(record []interface{}, col int) interface{} {
+	if col < len(record) {
+		return record[col]
+	}
+	return nil
+}
+
+// resultColumn returns the index of the column named name of result, or -1.
+func resultColumn(result *util.QueryResult, name string) int {
+	for i, column := range result.Columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+func newGroupByTestResult() *util.QueryResult {
+	return &util.QueryResult{
+		Columns: []string{"region", "revenue"},
+		Records: [][]interface{}{
+			{"eu", int64(10)},
+			{"us", 5.5},
+			{"eu", nil},
+			{"eu", int64(20)},
+			{"us", 4.5},
+			{"apac", nil},
+		},
+	}
+}
+
+func TestGroupRecords(t *testing.T) {
+	result := newGroupByTestResult()
+	err := groupRecords(result, "region", []AggregationSpec{
+		{Field: "revenue", Function: "sum"},
+		{Field: "revenue", Function: "avg"},
+		{Field: "revenue", Function: "min"},
+		{Field: "revenue", Function: "max", Alias: "top"},
+		{Field: "revenue", Function: "count"},
+		{Field: "*", Function: "count"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"region", "sum_revenue", "avg_revenue", "min_revenue", "top", "count_revenue", "count"}, result.Columns)
+	require.Equal(t, [][]interface{}{
+		{"eu", 30.0, 15.0, 10.0, 20.0, int64(2), int64(3)},
+		{"us", 10.0, 5.0, 4.5, 5.5, int64(2), int64(2)},
+		{"apac", nil, nil, nil, nil, int64(0), int64(1)},
+	}, result.Records)
+}
+
+func TestGroupRecordsErrors(t *testing.T) {
+	require.Error(t, groupRecords(newGroupByTestResult(), "country", nil))
+	require.Error(t, groupRecords(newGroupByTestResult(), "region", []AggregationSpec{{Field: "revenue", Function: "median"}}))
+	require.Error(t, groupRecords(newGroupByTestResult(), "region", []AggregationSpec{{Field: "cost", Function: "sum"}}))
+	require.Error(t, groupRecords(newGroupByTestResult(), "revenue", []AggregationSpec{{Field: "region", Function: "sum"}}))
+
+	result := &util.QueryResult{Columns: []string{"id"}}
+	for i := 0; i <= maxGroups; i++ {
+		result.Records = append(result.Records, []interface{}{fmt.Sprint(i)})
+	}
+	require.Error(t, groupRecords(result, "id", nil))
+}
+
+func TestGroupByQuery(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{newGroupByTestResult()}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select region, revenue from orders", "groupBy": "region",
+		  "aggregations": [{"field": "revenue", "function": "sum"}]}`)
+	require.NoError(t, resp.Error)
+	frame := resp.Frames[0]
+	require.Equal(t, 3, frame.Rows())
+	sum, _ := frame.FieldByName("sum_revenue")
+	require.Equal(t, 30.0, *sum.At(0).(*float64))
+}
+
+func TestGroupByQueryWithGeoFilter(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"region", "location"},
+		Records: [][]interface{}{
+			{"eu", &latlng.LatLng{Latitude: 48.85, Longitude: 2.35}},
+			{"eu", &latlng.LatLng{Latitude: 48.86, Longitude: 2.34}},
+			{"us", &latlng.LatLng{Latitude: 48.84, Longitude: 2.36}},
+		},
+	}
+	mock := &mockExecutor{results: []*util.QueryResult{result}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select region, location from stores", "groupBy": "region",
+		  "geoFilter": {"field": "location", "centerLat": 48.85, "centerLon": 2.35, "radiusKm": 10}}`)
+	require.NoError(t, resp.Error)
+	frame := resp.Frames[0]
+	require.Equal(t, 2, frame.Rows())
+	_, idx := frame.FieldByName(distanceField)
+	require.Equal(t, -1, idx)
+	_, err := frame.MarshalArrow()
+	require.NoError(t, err)
+}
@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcStatusToBackendStatus returns the backend status matching the gRPC
+// status of a Firestore error, and its message. Errors that are not gRPC
+// errors are reported as bad requests.
+func grpcStatusToBackendStatus(err error) (backend.Status, string) {
+	message := err.Error()
+	switch status.Code(err) {
+	case codes.NotFound:
+		return backend.StatusNotFound, message
+	case codes.PermissionDenied:
+		return backend.StatusForbidden, message + "; check service account IAM roles"
+	case codes.Unauthenticated:
+		return backend.StatusUnauthorized, message
+	case codes.ResourceExhausted:
+		return backend.StatusTooManyRequests, message
+	case codes.Unavailable:
+		return backend.StatusBadGateway, message
+	case codes.DeadlineExceeded:
+		return backend.StatusTimeout, message
+	}
+	return backend.StatusBadRequest, message
+}
+
+// firestoreErrorResponse returns the error response of a failed Firestore
+// call, prefixing its message with prefix when set.
+func firestoreErrorResponse(prefix string, err error) backend.DataResponse {
+	s, message := grpcStatusToBackendStatus(err)
+	if prefix != "" {
+		message = prefix + ": " + message
+	}
+	return backend.ErrDataResponse(s, message)
+}
@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCStatusToBackendStatus(t *testing.T) {
+	tests := map[codes.Code]backend.Status{
+		codes.NotFound:          backend.StatusNotFound,
+		codes.PermissionDenied:  backend.StatusForbidden,
+		codes.Unauthenticated:   backend.StatusUnauthorized,
+		codes.ResourceExhausted: backend.StatusTooManyRequests,
+		codes.Unavailable:       backend.StatusBadGateway,
+		codes.DeadlineExceeded:  backend.StatusTimeout,
+		codes.InvalidArgument:   backend.StatusBadRequest,
+	}
+	for code, expected := range tests {
+		s, _ := grpcStatusToBackendStatus(status.Error(code, "failed"))
+		require.Equal(t, expected, s, code.String())
+	}
+
+	s, message := grpcStatusToBackendStatus(errors.New("syntax error"))
+	require.Equal(t, backend.StatusBadRequest, s)
+	require.Equal(t, "syntax error", message)
+}
+
+func TestQueryPermissionDenied(t *testing.T) {
+	mock := &mockExecutor{errs: []error{status.Error(codes.PermissionDenied, "missing permissions")}}
+
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"}, `{"query": "select id from users"}`)
+	require.Equal(t, backend.StatusForbidden, resp.Status)
+	require.Contains(t, resp.Error.Error(), "check service account IAM roles")
+}
@@ -0,0 +1,239 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultHealthCheckRetryDelay = 500 * time.Millisecond
+	// maxHealthCollectionCount caps the root collections counted by the
+	// health check.
+	maxHealthCollectionCount = 50
+	firestoreModulePath      = "cloud.google.com/go/firestore"
+	// uncountedCollections is returned by pings that do not list the root
+	// collections.
+	uncountedCollections = -1
+
+	// healthCheckCollection holds the sentinel document created by the health
+	// check when CreateHealthCollection is set.
+	healthCheckCollection = "_grafana_health_check_"
+	healthCheckDocument   = "sentinel"
+)
+
+// pingFirestore checks that Firestore can be reached by listing the root
+// collections of the database, and returns their number, up to
+// maxHealthCollectionCount. An empty database is healthy. With
+// HealthCheckCollection, it reads a document of that collection instead,
+// for service accounts that cannot list collections, and returns
+// uncountedCollections. With
+// CreateHealthCollection, it also creates the health check sentinel document
+// when missing.
+func pingFirestore(ctx context.Context, pCtx backend.PluginContext) (int, error) {
+	// Invalid settings are reported by newFirestoreClient
+	var settings FirestoreSettings
+	_ = json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings)
+	if settings.CreateHealthCollection && !settings.WriteEnabled {
+		return 0, errors.New("CreateHealthCollection requires WriteEnabled")
+	}
+
+	if settings.WorkloadIdentityConfigFile != "" && emulatorHost(settings) == "" {
+		if err := checkWorkloadIdentity(ctx, settings.WorkloadIdentityConfigFile); err != nil {
+			return 0, err
+		}
+	}
+
+	client, err := newFirestoreClient(ctx, pCtx)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	if settings.HealthCheckCollection != "" {
+		ref, err := collectionRef(client, settings.HealthCheckCollection)
+		if err != nil {
+			return 0, err
+		}
+		_, err = ref.Limit(1).Documents(ctx).Next()
+		if err := healthCollectionError(settings.HealthCheckCollection, err); err != nil {
+			return 0, err
+		}
+		if settings.CreateHealthCollection {
+			return uncountedCollections, ensureHealthCollection(ctx, client)
+		}
+		return uncountedCollections, nil
+	}
+
+	count := 0
+	it := client.Collections(ctx)
+	for count < maxHealthCollectionCount {
+		collection, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			log.DefaultLogger.Error("client.Collections ", err)
+			return 0, fmt.Errorf("firestore.Collections: %w", err)
+		}
+		if count == 0 {
+			log.DefaultLogger.Debug("First collections: ", collection.ID)
+		}
+		count++
+	}
+
+	if settings.CreateHealthCollection {
+		return count, ensureHealthCollection(ctx, client)
+	}
+	return count, nil
+}
+
+// healthCollectionError returns the error of reading the first document of
+// the health check collection, if any. An empty collection is healthy.
+func healthCollectionError(collection string, err error) error {
+	if err == nil || errors.Is(err, iterator.Done) {
+		return nil
+	}
+	log.DefaultLogger.Error("Reading the health check collection", "collection", collection, "error", err)
+	return fmt.Errorf("reading collection %q: %w", collection, err)
+}
+
+// ensureHealthCollection creates the health check sentinel document unless
+// it already exists.
+func ensureHealthCollection(ctx context.Context, client *firestore.Client) error {
+	_, err := client.Collection(healthCheckCollection).Doc(healthCheckDocument).Create(ctx, map[string]interface{}{
+		"createdAt": firestore.ServerTimestamp,
+	})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("creating health check collection: %v", err)
+	}
+	if err == nil {
+		log.DefaultLogger.Info("Created health check collection", "collection", healthCheckCollection)
+	}
+	return nil
+}
+
+// checkFirestore pings Firestore up to HealthCheckRetries times (default 1),
+// waiting HealthCheckRetryDelayMs (default 500) between attempts, and returns
+// the number of root collections found, or the error of the last attempt if
+// none succeeded.
+func (d *Datasource) checkFirestore(ctx context.Context, pCtx backend.PluginContext) (int, error) {
+	ping := pingFirestore
+	if d.ping != nil {
+		ping = d.ping
+	}
+
+	// Invalid settings are reported by the ping itself
+	var settings FirestoreSettings
+	_ = json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings)
+	attempts := settings.HealthCheckRetries
+	if attempts <= 0 {
+		attempts = 1
+	}
+	delay := defaultHealthCheckRetryDelay
+	if settings.HealthCheckRetryDelayMs > 0 {
+		delay = time.Duration(settings.HealthCheckRetryDelayMs) * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		count, err := ping(ctx, pCtx)
+		if err == nil || attempt >= attempts {
+			return count, err
+		}
+		log.DefaultLogger.Warn("Health check failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// healthDetails are the JSON details of the health check result.
+type healthDetails struct {
+	ProjectID           string `json:"projectId"`
+	DatabaseName        string `json:"databaseName"`
+	AuthMethod          string `json:"authMethod"`
+	CollectionCount     *int   `json:"collectionCount,omitempty"`
+	SDKVersion          string `json:"sdkVersion"`
+	ConnectionLatencyMs int64  `json:"connectionLatencyMs"`
+	// Code, Error and Hint describe a failed health check.
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	Hint  string `json:"hint,omitempty"`
+}
+
+// setError records the gRPC status code of err, err itself and how to fix
+// it.
+func (h *healthDetails) setError(err error, settings FirestoreSettings, pCtx backend.PluginContext) {
+	h.Code = status.Code(err).String()
+	h.Error = err.Error()
+	h.Hint = healthHint(err, settings, pCtx)
+}
+
+// authMethod describes how the datasource authenticates to Firestore.
+func authMethod(settings FirestoreSettings, pCtx backend.PluginContext) string {
+	switch {
+	case emulatorHost(settings) != "":
+		return "emulator"
+	case settings.WorkloadIdentityConfigFile != "":
+		return "workload identity federation"
+	case settings.UseApplicationDefaultCredentials:
+		return "application default credentials"
+	case selectServiceAccount(settings, pCtx) != "":
+		return "service account"
+	}
+	return "application default credentials"
+}
+
+// firestoreSDKVersion returns the version of the Firestore Go SDK the plugin
+// is built with.
+func firestoreSDKVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == firestoreModulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "unknown"
+}
+
+// healthHint returns how to fix a failed health check, or an empty string.
+func healthHint(err error, settings FirestoreSettings, pCtx backend.PluginContext) string {
+	switch status.Code(err) {
+	case codes.PermissionDenied:
+		account := "the service account"
+		if email := serviceAccountEmail(selectServiceAccount(settings, pCtx)); email != "" {
+			account += " " + email
+		}
+		return "PERMISSION_DENIED: grant roles/datastore.viewer to " + account
+	case codes.Unauthenticated:
+		return "UNAUTHENTICATED: check that the service account key is valid and not revoked"
+	case codes.NotFound:
+		return "NOT_FOUND: check the project ID and database name, and that Firestore is enabled in the project"
+	case codes.Unavailable:
+		return "UNAVAILABLE: check that Grafana can reach firestore.googleapis.com"
+	}
+	return ""
+}
+
+// serviceAccountEmail returns the client email of a service account key, or
+// an empty string.
+func serviceAccountEmail(serviceAccount string) string {
+	var key struct {
+		ClientEmail string `json:"client_email"`
+	}
+	_ = json.Unmarshal([]byte(serviceAccount), &key)
+	return key.ClientEmail
+}
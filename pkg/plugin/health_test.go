@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func checkHealthWithPings(t *testing.T, settings FirestoreSettings, pings []error) (*backend.CheckHealthResult, int) {
+	jsonSettings, err := json.Marshal(settings)
+	require.NoError(t, err)
+
+	calls := 0
+	ds := &Datasource{ping: func(context.Context, backend.PluginContext) (int, error) {
+		err := pings[min(calls, len(pings)-1)]
+		calls++
+		return 2, err
+	}}
+	result, err := ds.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+	})
+	require.NoError(t, err)
+	return result, calls
+}
+
+func TestHealthCheckRetries(t *testing.T) {
+	unavailable := errors.New("unavailable")
+
+	result, calls := checkHealthWithPings(t, FirestoreSettings{HealthCheckRetries: 3, HealthCheckRetryDelayMs: 1},
+		[]error{unavailable, unavailable, nil})
+	require.Equal(t, backend.HealthStatusOk, result.Status)
+	require.Equal(t, 3, calls)
+
+	result, calls = checkHealthWithPings(t, FirestoreSettings{HealthCheckRetries: 2, HealthCheckRetryDelayMs: 1},
+		[]error{unavailable, unavailable, nil})
+	require.Equal(t, backend.HealthStatusError, result.Status)
+	require.Equal(t, "unavailable", result.Message)
+	require.Equal(t, 2, calls)
+
+	// A single attempt by default
+	_, calls = checkHealthWithPings(t, FirestoreSettings{}, []error{unavailable})
+	require.Equal(t, 1, calls)
+}
+
+func TestCheckHealthCreateHealthCollection(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	sentinel := client.Collection(healthCheckCollection).Doc(healthCheckDocument)
+	_, _ = sentinel.Delete(ctx)
+
+	settings, err := json.Marshal(FirestoreSettings{ProjectId: "test", WriteEnabled: true, CreateHealthCollection: true})
+	require.NoError(t, err)
+	req := &backend.CheckHealthRequest{PluginContext: backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: settings},
+	}}
+
+	// Created on the first check, then left as is
+	for i := 0; i < 2; i++ {
+		result, err := (&Datasource{}).CheckHealth(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, backend.HealthStatusOk, result.Status, result.Message)
+	}
+	doc, err := sentinel.Get(ctx)
+	require.NoError(t, err)
+	require.True(t, doc.Exists())
+}
+
+func TestPingFirestoreCreateHealthCollectionRequiresWrites(t *testing.T) {
+	settings, err := json.Marshal(FirestoreSettings{ProjectId: "test", CreateHealthCollection: true})
+	require.NoError(t, err)
+
+	_, err = pingFirestore(context.Background(), backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: settings},
+	})
+	require.EqualError(t, err, "CreateHealthCollection requires WriteEnabled")
+}
+
+func TestHealthCheckDetails(t *testing.T) {
+	// The auth method is reported as "emulator" when connecting to one.
+	t.Setenv(emulatorHostEnvVar, "")
+	result, _ := checkHealthWithPings(t, FirestoreSettings{ProjectId: "test"}, []error{nil})
+	var details healthDetails
+	require.NoError(t, json.Unmarshal(result.JSONDetails, &details))
+	require.Equal(t, "test", details.ProjectID)
+	require.Equal(t, "(default)", details.DatabaseName)
+	require.Equal(t, 2, *details.CollectionCount)
+	require.NotEmpty(t, details.SDKVersion)
+	require.Empty(t, details.Hint)
+
+	denied := status.Error(codes.PermissionDenied, "missing permissions")
+	result, _ = checkHealthWithPings(t, FirestoreSettings{ProjectId: "test", UseApplicationDefaultCredentials: true}, []error{denied})
+	details = healthDetails{}
+	require.NoError(t, json.Unmarshal(result.JSONDetails, &details))
+	require.Equal(t, "application default credentials", details.AuthMethod)
+	require.Nil(t, details.CollectionCount)
+	require.Equal(t, "PermissionDenied", details.Code)
+	require.Equal(t, "rpc error: code = PermissionDenied desc = missing permissions", details.Error)
+	require.Equal(t, "PERMISSION_DENIED: grant roles/datastore.viewer to the service account", details.Hint)
+}
+
+func TestHealthHintServiceAccountEmail(t *testing.T) {
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+		DecryptedSecureJSONData: map[string]string{"serviceAccount": `{"client_email": "grafana@p.iam.gserviceaccount.com"}`},
+	}}
+	hint := healthHint(status.Error(codes.PermissionDenied, "denied"), FirestoreSettings{}, pCtx)
+	require.Equal(t, "PERMISSION_DENIED: grant roles/datastore.viewer to the service account grafana@p.iam.gserviceaccount.com", hint)
+	require.Empty(t, healthHint(errors.New("other"), FirestoreSettings{}, pCtx))
+}
+
+func TestHealthCollectionError(t *testing.T) {
+	require.NoError(t, healthCollectionError("status", nil))
+	// An empty collection is healthy
+	require.NoError(t, healthCollectionError("status", iterator.Done))
+
+	err := healthCollectionError("status", status.Error(codes.PermissionDenied, "missing permissions"))
+	require.EqualError(t, err, `reading collection "status": rpc error: code = PermissionDenied desc = missing permissions`)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Equal(t, "PERMISSION_DENIED: grant roles/datastore.viewer to the service account", healthHint(err, FirestoreSettings{}, backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+	}))
+}
+
+func TestCheckHealthCollection(t *testing.T) {
+	settings, err := json.Marshal(FirestoreSettings{ProjectId: "test", HealthCheckCollection: "empty_health_collection"})
+	require.NoError(t, err)
+	result, err := (&Datasource{}).CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: settings},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, backend.HealthStatusOk, result.Status, result.Message)
+
+	var details healthDetails
+	require.NoError(t, json.Unmarshal(result.JSONDetails, &details))
+	require.Nil(t, details.CollectionCount)
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+const defaultHistogramBuckets = 10
+
+// newHistogramFrame counts the numeric values of field in result over buckets
+// equal-width bins spanning their range, and returns a frame with the
+// bucket_min, bucket_max and count of each bin. The last bin includes its
+// upper bound. Non-numeric and non-finite values are ignored, and a single
+// bin is returned when all values are equal.
+func newHistogramFrame(result *util.QueryResult, field string, buckets int) (*data.Frame, error) {
+	if buckets < 0 {
+		return nil, fmt.Errorf("HistogramBuckets must be positive, got %d", buckets)
+	}
+	if buckets == 0 {
+		buckets = defaultHistogramBuckets
+	}
+	column := -1
+	for i, name := range result.Columns {
+		if name == field {
+			column = i
+			break
+		}
+	}
+	if column == -1 {
+		return nil, fmt.Errorf("histogram field %q not found", field)
+	}
+
+	var values []float64
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, record := range result.Records {
+		if column >= len(record) {
+			continue
+		}
+		v, ok := toFloat64(record[column])
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		values = append(values, v)
+		min, max = math.Min(min, v), math.Max(max, v)
+	}
+
+	frame := data.NewFrame("histogram",
+		data.NewField("bucket_min", nil, []float64{}),
+		data.NewField("bucket_max", nil, []float64{}),
+		data.NewField("count", nil, []int64{}),
+	)
+	if len(values) == 0 {
+		return frame, nil
+	}
+	if min == max {
+		buckets = 1
+	}
+
+	width := (max - min) / float64(buckets)
+	counts := make([]int64, buckets)
+	for _, v := range values {
+		bucket := buckets - 1
+		if width > 0 {
+			bucket = int((v - min) / width)
+			if bucket >= buckets {
+				bucket = buckets - 1
+			}
+		}
+		counts[bucket]++
+	}
+	for i, count := range counts {
+		upper := min + float64(i+1)*width
+		if i == buckets-1 {
+			upper = max
+		}
+		frame.AppendRow(min+float64(i)*width, upper, count)
+	}
+	return frame, nil
+}
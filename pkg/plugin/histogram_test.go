@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHistogramFrame(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"name", "value"}}
+	for i := 0; i < 100; i++ {
+		result.Records = append(result.Records, []interface{}{"doc", float64(i) + 0.5})
+	}
+	result.Records = append(result.Records, []interface{}{"text", "n/a"}, []interface{}{"missing", nil})
+
+	frame, err := newHistogramFrame(result, "value", 10)
+	require.NoError(t, err)
+	require.Equal(t, 10, frame.Rows())
+	for i := 0; i < frame.Rows(); i++ {
+		require.InDelta(t, 10, frame.Fields[2].At(i).(int64), 1)
+	}
+	require.Equal(t, 0.5, frame.Fields[0].At(0))
+	require.Equal(t, 99.5, frame.Fields[1].At(9))
+}
+
+func TestNewHistogramFrameEqualValues(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"value"},
+		Records: [][]interface{}{{int64(3)}, {int64(3)}, {int64(3)}},
+	}
+
+	frame, err := newHistogramFrame(result, "value", 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, []interface{}{3.0, 3.0, int64(3)}, frame.RowCopy(0))
+}
+
+func TestNewHistogramFrameErrors(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"value"}}
+
+	_, err := newHistogramFrame(result, "missing", 10)
+	require.Error(t, err)
+
+	_, err = newHistogramFrame(result, "value", -1)
+	require.Error(t, err)
+
+	frame, err := newHistogramFrame(result, "value", 10)
+	require.NoError(t, err)
+	require.Equal(t, 0, frame.Rows())
+}
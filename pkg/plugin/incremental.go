@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// incrementalState holds the documents fetched so far by an IncrementalRefresh
+// query, keyed by document path, and the latest time field value among them.
+type incrementalState struct {
+	mu sync.Mutex
+	// key identifies the query the state belongs to; the state is reset
+	// when the query of the RefID changes.
+	key     string
+	columns []string
+	ids     []string
+	records map[string]map[string]interface{}
+	lastMax interface{}
+}
+
+// incrementalFetchFunc fetches the documents whose time field is after the
+// given value, or all documents when after is nil. It returns their records
+// along with the document ID and time field value of each record.
+type incrementalFetchFunc func(ctx context.Context, after interface{}) (result *util.QueryResult, ids []string, times []interface{}, err error)
+
+// refresh fetches the documents added since the last refresh and merges them
+// into the state, replacing documents that were fetched before.
+func (s *incrementalState) refresh(ctx context.Context, key string, fetch incrementalFetchFunc) (*util.QueryResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key != key {
+		s.key = key
+		s.columns, s.ids, s.lastMax = nil, nil, nil
+		s.records = map[string]map[string]interface{}{}
+	}
+
+	result, ids, times, err := fetch(ctx, s.lastMax)
+	if err != nil {
+		return nil, err
+	}
+	for i, record := range result.Records {
+		if _, ok := s.records[ids[i]]; !ok {
+			s.ids = append(s.ids, ids[i])
+		}
+		values := make(map[string]interface{}, len(record))
+		for j, value := range record {
+			if j < len(result.Columns) {
+				if !containsString(s.columns, result.Columns[j]) {
+					s.columns = append(s.columns, result.Columns[j])
+				}
+				values[result.Columns[j]] = value
+			}
+		}
+		s.records[ids[i]] = values
+
+		if isLater(times[i], s.lastMax) {
+			s.lastMax = times[i]
+		}
+	}
+
+	merged := &util.QueryResult{
+		Columns: append([]string(nil), s.columns...),
+		Records: make([][]interface{}, len(s.ids)),
+	}
+	for i, id := range s.ids {
+		record := make([]interface{}, len(s.columns))
+		for j, column := range s.columns {
+			record[j] = s.records[id][column]
+		}
+		merged.Records[i] = record
+	}
+	return merged, nil
+}
+
+// isLater reports whether v is after current. Values of a different type
+// than current are ignored.
+func isLater(v, current interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if current == nil {
+		return true
+	}
+	if fmt.Sprintf("%T", v) != fmt.Sprintf("%T", current) {
+		return false
+	}
+	return compareValues(v, current) > 0
+}
+
+// incrementalQuery runs an IncrementalRefresh query directly against
+// Firestore. Only documents whose IncrementalTimeField is after the latest
+// value seen by the previous run of the same RefID are fetched. Like other
+// queries, a query without LIMIT clause is limited to the DefaultLimit setting.
+func (d *Datasource) incrementalQuery(ctx context.Context, pCtx backend.PluginContext, settings FirestoreSettings, refID string, qm FirestoreQuery) (*util.QueryResult, error) {
+	if qm.IncrementalTimeField == "" {
+		return nil, errors.New("IncrementalTimeField is required for IncrementalRefresh")
+	}
+	stmt, err := parseSelectStatement(qm.Query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Limit == 0 {
+		stmt.Limit = queryLimit(settings)
+	}
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	fetch := func(ctx context.Context, after interface{}) (*util.QueryResult, []string, []interface{}, error) {
+		q, err := stmt.firestoreQuery(client)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if after != nil {
+			q = q.Where(qm.IncrementalTimeField, ">", after)
+		}
+		docs, err := q.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ids := make([]string, len(docs))
+		times := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.Ref.Path
+			times[i], _ = doc.DataAt(qm.IncrementalTimeField)
+		}
+		return stmt.result(docs), ids, times, nil
+	}
+
+	v, _ := d.incrementalStates.LoadOrStore(refID, &incrementalState{})
+	return v.(*incrementalState).refresh(ctx, qm.IncrementalTimeField+"\x00"+qm.Query, fetch)
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// incrementalDocs serves the documents of docs whose time is after the
+// requested value, recording the requested values in afters.
+func incrementalDocs(docs *[][]interface{}, afters *[]interface{}) incrementalFetchFunc {
+	return func(ctx context.Context, after interface{}) (*util.QueryResult, []string, []interface{}, error) {
+		*afters = append(*afters, after)
+		result := &util.QueryResult{Columns: []string{"id", "value", "time"}}
+		var ids []string
+		var times []interface{}
+		for _, doc := range *docs {
+			if after != nil && !doc[2].(time.Time).After(after.(time.Time)) {
+				continue
+			}
+			result.Records = append(result.Records, doc)
+			ids = append(ids, "events/"+doc[0].(string))
+			times = append(times, doc[2])
+		}
+		return result, ids, times, nil
+	}
+}
+
+func TestIncrementalRefresh(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := [][]interface{}{
+		{"a", 1, start},
+		{"b", 2, start.Add(time.Minute)},
+	}
+	var afters []interface{}
+	var state incrementalState
+
+	first, err := state.refresh(context.Background(), "q", incrementalDocs(&docs, &afters))
+	require.NoError(t, err)
+	require.Len(t, first.Records, 2)
+
+	// No new documents: the cached result is returned as is
+	second, err := state.refresh(context.Background(), "q", incrementalDocs(&docs, &afters))
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, []interface{}{nil, start.Add(time.Minute)}, afters)
+
+	// A new document is appended and an updated one replaces its previous version
+	docs = append(docs, []interface{}{"c", 3, start.Add(2 * time.Minute)}, []interface{}{"a", 10, start.Add(3 * time.Minute)})
+	third, err := state.refresh(context.Background(), "q", incrementalDocs(&docs, &afters))
+	require.NoError(t, err)
+	require.Equal(t, [][]interface{}{
+		{"a", 10, start.Add(3 * time.Minute)},
+		{"b", 2, start.Add(time.Minute)},
+		{"c", 3, start.Add(2 * time.Minute)},
+	}, third.Records)
+
+	// A different query starts over
+	afters = nil
+	_, err = state.refresh(context.Background(), "other", incrementalDocs(&docs, &afters))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{nil}, afters)
+}
@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+var indexURLPattern = regexp.MustCompile(`https://console\.firebase\.google\.com/\S+`)
+
+// missingIndexResponse returns the response of a query failing for lack of a
+// composite index, holding a notice that links to the index creation page of
+// the Firebase console. It returns false for any other error.
+func missingIndexResponse(err error) (backend.DataResponse, bool) {
+	message := err.Error()
+	if !strings.Contains(message, "The query requires an index") {
+		return backend.DataResponse{}, false
+	}
+	link := strings.TrimRight(indexURLPattern.FindString(message), `.,;)"'`)
+	if link == "" {
+		return backend.DataResponse{}, false
+	}
+
+	frame := data.NewFrame("response")
+	frame.AppendNotices(data.Notice{
+		Severity: data.NoticeSeverityError,
+		Text:     "The query requires a composite index that does not exist yet. Follow the link to create it.",
+		Link:     link,
+	})
+	return backend.DataResponse{
+		Frames: data.Frames{frame},
+		Error:  errors.New("missing index: " + message),
+		Status: backend.StatusBadRequest,
+	}, true
+}
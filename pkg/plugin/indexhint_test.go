@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const indexURL = "https://console.firebase.google.com/v1/r/project/test/firestore/indexes?create_composite=ClBwcm9qZWN0cy90ZXN0"
+
+func TestMissingIndexResponse(t *testing.T) {
+	err := status.Error(codes.FailedPrecondition, "The query requires an index. You can create it here: "+indexURL)
+	res, ok := missingIndexResponse(err)
+	require.True(t, ok)
+	require.Error(t, res.Error)
+	require.Equal(t, backend.StatusBadRequest, res.Status)
+	notices := res.Frames[0].Meta.Notices
+	require.Len(t, notices, 1)
+	require.Equal(t, data.NoticeSeverityError, notices[0].Severity)
+	require.Equal(t, indexURL, notices[0].Link)
+
+	_, ok = missingIndexResponse(errors.New("permission denied"))
+	require.False(t, ok)
+	_, ok = missingIndexResponse(errors.New("The query requires an index"))
+	require.False(t, ok)
+}
+
+func TestMissingIndexQuery(t *testing.T) {
+	mock := &mockExecutor{errs: []error{
+		status.Error(codes.FailedPrecondition, "The query requires an index. You can create it here: "+indexURL+"."),
+	}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test", RetryMaxAttempts: 1},
+		`{"query": "select * from users where age > 1 order by name"}`)
+	require.Error(t, resp.Error)
+	require.Equal(t, "A", resp.Frames[0].RefID)
+	require.Equal(t, indexURL, resp.Frames[0].Meta.Notices[0].Link)
+}
@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/xwb1989/sqlparser"
+)
+
+// maxInValues is the maximum number of values of a Firestore in or not-in
+// filter.
+const maxInValues = 30
+
+var inVarPattern = regexp.MustCompile(`(?i)(\bIN\s*)\(\s*\$\{(\w+)\}\s*\)`)
+
+// expandMultiValueVars replaces the IN (${name}) and NOT IN (${name}) lists
+// of query with the quoted values of the multi-value variable name in vars.
+func expandMultiValueVars(query string, vars map[string][]string) (string, error) {
+	var err error
+	query = inVarPattern.ReplaceAllStringFunc(query, func(list string) string {
+		m := inVarPattern.FindStringSubmatch(list)
+		values, ok := vars[m[2]]
+		if !ok {
+			return list
+		}
+		if len(values) == 0 {
+			err = fmt.Errorf("multi-value variable %q has no values", m[2])
+			return list
+		}
+		literals := make([]string, len(values))
+		for i, v := range values {
+			literals[i] = sqlparser.String(sqlparser.NewStrVal([]byte(v)))
+		}
+		return m[1] + "(" + strings.Join(literals, ", ") + ")"
+	})
+	if err != nil {
+		return "", err
+	}
+	return query, nil
+}
+
+// checkInLists returns an error when an IN or NOT IN list of query has more
+// values than Firestore allows. Queries that do not parse are left to the
+// executor to report.
+func checkInLists(query string) error {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil
+	}
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		expr, ok := node.(*sqlparser.ComparisonExpr)
+		if !ok || expr.Operator != sqlparser.InStr && expr.Operator != sqlparser.NotInStr {
+			return true, nil
+		}
+		if values, ok := expr.Right.(sqlparser.ValTuple); ok && len(values) > maxInValues {
+			return false, fmt.Errorf("%s list of %s has %d values, Firestore allows at most %d",
+				strings.ToUpper(expr.Operator), sqlparser.String(expr.Left), len(values), maxInValues)
+		}
+		return true, nil
+	}, stmt)
+}
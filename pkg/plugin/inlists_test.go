@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandMultiValueVars(t *testing.T) {
+	vars := map[string][]string{"status": {"active", "pending", "it's"}, "none": {}}
+
+	query, err := expandMultiValueVars("select * from tasks where status in (${status})", vars)
+	require.NoError(t, err)
+	require.Equal(t, `select * from tasks where status in ('active', 'pending', 'it\'s')`, query)
+
+	query, err = expandMultiValueVars("select * from tasks where status NOT IN ( ${status} )", vars)
+	require.NoError(t, err)
+	require.Equal(t, `select * from tasks where status NOT IN ('active', 'pending', 'it\'s')`, query)
+
+	query, err = expandMultiValueVars("select * from tasks where status in (${other})", vars)
+	require.NoError(t, err)
+	require.Equal(t, "select * from tasks where status in (${other})", query)
+
+	_, err = expandMultiValueVars("select * from tasks where status in (${none})", vars)
+	require.Error(t, err)
+}
+
+func TestCheckInLists(t *testing.T) {
+	values := make([]string, maxInValues+1)
+	for i := range values {
+		values[i] = fmt.Sprint(i)
+	}
+	require.NoError(t, checkInLists("select * from tasks where id in ("+strings.Join(values[:maxInValues], ", ")+")"))
+	require.Error(t, checkInLists("select * from tasks where id in ("+strings.Join(values, ", ")+")"))
+	require.Error(t, checkInLists("select * from tasks where a = 1 and id not in ("+strings.Join(values, ", ")+")"))
+	require.NoError(t, checkInLists("not sql"))
+}
+
+func TestInListTooLong(t *testing.T) {
+	values := make([]string, maxInValues+1)
+	for i := range values {
+		values[i] = fmt.Sprintf("v%d", i)
+	}
+	ex := &mockExecutor{}
+	ds := newMockDatasource(ex)
+	vars := `{"status": ["` + strings.Join(values, `", "`) + `"]}`
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"},
+		`{"query": "select * from tasks where status in (${status})", "multiValueVars": `+vars+`}`)
+	require.Error(t, resp.Error)
+	require.Equal(t, backend.StatusBadRequest, resp.Status)
+	require.Zero(t, ex.calls())
+}
+
+func TestQueryDataInOperators(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	for id, status := range map[string]string{"t1": "active", "t2": "pending", "t3": "done"} {
+		_, err := client.Collection("in_tasks").Doc(id).Set(ctx, map[string]interface{}{"status": status})
+		require.NoError(t, err)
+	}
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := runQuery(t, ds, settings, `{"query": "select status from in_tasks where status in (${status})", "multiValueVars": {"status": ["active", "pending"]}}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, resp.Frames[0].Rows())
+
+	resp = runQuery(t, ds, settings, `{"query": "select status from in_tasks where status not in ('active', 'pending')"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 1, resp.Frames[0].Rows())
+}
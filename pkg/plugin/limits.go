@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+const (
+	// defaultQueryLimit is the limit of queries without a LIMIT clause when
+	// the DefaultLimit setting is not set.
+	defaultQueryLimit = 1000
+	// defaultMaxDocuments caps the documents returned by a query when the
+	// MaxDocuments setting is not set.
+	defaultMaxDocuments = 10000
+)
+
+// queryLimit returns the limit applied to queries without a LIMIT clause.
+// Firestore bills every document read, so this keeps queries from scanning
+// whole collections by accident.
+func queryLimit(settings FirestoreSettings) int {
+	if settings.DefaultLimit > 0 {
+		return settings.DefaultLimit
+	}
+	return defaultQueryLimit
+}
+
+// maxDocuments returns the maximum number of documents a query returns.
+func maxDocuments(settings FirestoreSettings) int {
+	if settings.MaxDocuments > 0 {
+		return settings.MaxDocuments
+	}
+	return defaultMaxDocuments
+}
+
+// truncateRecords drops the records of result beyond the MaxDocuments
+// setting and returns the notice to attach to the frame, or nil when result
+// has fewer records.
+func truncateRecords(result *util.QueryResult, settings FirestoreSettings) *data.Notice {
+	maxDocuments := maxDocuments(settings)
+	if len(result.Records) < maxDocuments {
+		return nil
+	}
+	result.Records = result.Records[:maxDocuments]
+	return &data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     fmt.Sprintf("Results truncated at %d documents", maxDocuments),
+	}
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLimit(t *testing.T) {
+	require.Equal(t, defaultQueryLimit, queryLimit(FirestoreSettings{}))
+	require.Equal(t, 50, queryLimit(FirestoreSettings{DefaultLimit: 50}))
+}
+
+func TestTruncateRecords(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"id"}, Records: [][]interface{}{{"1"}, {"2"}, {"3"}}}
+	require.Nil(t, truncateRecords(result, FirestoreSettings{MaxDocuments: 5}))
+	require.Len(t, result.Records, 3)
+
+	notice := truncateRecords(result, FirestoreSettings{MaxDocuments: 2})
+	require.NotNil(t, notice)
+	require.Equal(t, "Results truncated at 2 documents", notice.Text)
+	require.Len(t, result.Records, 2)
+}
+
+func TestQueryMaxDocuments(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}, Records: [][]interface{}{{"1"}, {"2"}, {"3"}}}}}
+	ds := newMockDatasource(mock)
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test", MaxDocuments: 2}, `{"query": "select id from users"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, resp.Frames[0].Rows())
+	require.Equal(t, "Results truncated at 2 documents", resp.Frames[0].Meta.Notices[0].Text)
+}
+
+func TestQueryDataStructuredQueryDefaultLimit(t *testing.T) {
+	resp := runQuery(t, &Datasource{}, FirestoreSettings{ProjectId: "test", DefaultLimit: 2}, `{"structuredQuery": {"collection": "users"}}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, resp.Frames[0].Rows())
+}
+
+func TestQueryDataIncrementalRefreshDefaultLimit(t *testing.T) {
+	resp := runQuery(t, &Datasource{}, FirestoreSettings{ProjectId: "test", DefaultLimit: 2},
+		`{"query": "select * from users", "incrementalRefresh": true, "incrementalTimeField": "id"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, resp.Frames[0].Rows())
+}
+
+func TestQueryDataExplainDefaultLimit(t *testing.T) {
+	resp := runQuery(t, &Datasource{}, FirestoreSettings{ProjectId: "test", DefaultLimit: 2}, `{"query": "select * from users", "explain": true}`)
+	require.NoError(t, resp.Error)
+	explain := resp.Frames[1]
+	for i := 0; i < explain.Rows(); i++ {
+		if explain.Fields[0].At(i) == "results_returned" {
+			require.Equal(t, "2", explain.Fields[1].At(i))
+			return
+		}
+	}
+	t.Fatal("results_returned missing from the explain frame")
+}
@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// liveChannelScope is the first segment of the live channel paths handled by
+// the datasource, which are of the form firestore/{collection}/{queryHash}.
+// The query of a StreamQuery is the FireQL query it selects documents with.
+const liveChannelScope = "firestore"
+
+// queryHash identifies a FireQL query in live channel paths: the first 8 hex
+// characters of its SHA256.
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// liveChannelPath returns the live channel path streaming the results of query.
+func liveChannelPath(query string) string {
+	return liveChannelScope + "/" + extractCollectionName(query) + "/" + queryHash(query)
+}
+
+// parseLiveChannelPath returns the collection and query hash of a live channel
+// path. The ds/{uid}/ prefix of the full Grafana Live channel is optional.
+func parseLiveChannelPath(path string) (collection, hash string, err error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 5 && parts[0] == "ds" {
+		parts = parts[2:]
+	}
+	if len(parts) != 3 || parts[0] != liveChannelScope || parts[1] == "" || len(parts[2]) != 8 {
+		return "", "", fmt.Errorf("invalid live channel path %q", path)
+	}
+	if _, err := hex.DecodeString(parts[2]); err != nil {
+		return "", "", fmt.Errorf("invalid query hash in live channel path %q", path)
+	}
+	return parts[1], parts[2], nil
+}
+
+func checkCollectionAllowed(settings FirestoreSettings, collection string) error {
+	if len(settings.AllowedCollections) == 0 || containsString(settings.AllowedCollections, collection) {
+		return nil
+	}
+	return fmt.Errorf("collection %q is not allowed for this datasource", collection)
+}
+
+// SubscribeStream accepts subscriptions to firestore/{collection}/{queryHash}
+// channels of allowed collections. When the subscription carries the query,
+// it must match the collection and hash of the path.
+func (d *Datasource) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	collection, hash, err := parseLiveChannelPath(req.Path)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+
+	var settings FirestoreSettings
+	if err := json.Unmarshal(req.PluginContext.DataSourceInstanceSettings.JSONData, &settings); err != nil {
+		return nil, err
+	}
+	if err := checkCollectionAllowed(settings, collection); err != nil {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusPermissionDenied}, err
+	}
+
+	if len(req.Data) > 0 {
+		var qm FirestoreQuery
+		if err := json.Unmarshal(req.Data, &qm); err != nil {
+			return nil, err
+		}
+		if qm.StreamQuery != nil {
+			qm.Query = qm.StreamQuery.query()
+		}
+		if extractCollectionName(qm.Query) != collection || queryHash(qm.Query) != hash {
+			return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+		}
+	}
+	return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+}
+
+// PublishStream rejects publications; live channels are only fed by RunStream.
+func (d *Datasource) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+}
+
+// RunStream sends the changes of the StreamQuery of the subscription to the
+// channel as they happen. Other queries are re-executed every
+// PollingIntervalSecs (default 30) and their frames sent to the channel.
+func (d *Datasource) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	var qm FirestoreQuery
+	if err := json.Unmarshal(req.Data, &qm); err != nil {
+		return err
+	}
+	if qm.StreamQuery != nil {
+		return d.runSnapshotStream(ctx, req.PluginContext, *qm.StreamQuery, sender)
+	}
+	interval := defaultPollingInterval
+	if qm.PollingIntervalSecs > 0 {
+		interval = time.Duration(qm.PollingIntervalSecs) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		res := d.query(ctx, req.PluginContext, backend.DataQuery{RefID: "A", JSON: req.Data})
+		if res.Error != nil {
+			log.DefaultLogger.Warn("Live query failed", "path", req.Path, "error", res.Error)
+		}
+		for _, frame := range res.Frames {
+			if err := sender.SendFrame(frame, data.IncludeAll); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
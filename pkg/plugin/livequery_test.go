@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func subscribeStream(t *testing.T, settings FirestoreSettings, path string, query *FirestoreQuery) (*backend.SubscribeStreamResponse, error) {
+	jsonSettings, err := json.Marshal(settings)
+	require.NoError(t, err)
+	req := &backend.SubscribeStreamRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Path: path,
+	}
+	if query != nil {
+		req.Data, err = json.Marshal(query)
+		require.NoError(t, err)
+	}
+	return (&Datasource{}).SubscribeStream(context.Background(), req)
+}
+
+func TestLiveChannelPath(t *testing.T) {
+	path := liveChannelPath("SELECT * FROM users")
+	require.Regexp(t, `^firestore/users/[0-9a-f]{8}$`, path)
+
+	collection, hash, err := parseLiveChannelPath("ds/abc123/" + path)
+	require.NoError(t, err)
+	require.Equal(t, "users", collection)
+	require.Equal(t, queryHash("SELECT * FROM users"), hash)
+
+	for _, invalid := range []string{"", "firestore/users", "other/users/0123abcd", "firestore//0123abcd", "firestore/users/xyz"} {
+		_, _, err := parseLiveChannelPath(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestSubscribeStream(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test", AllowedCollections: []string{"users"}}
+	query := &FirestoreQuery{Query: "SELECT * FROM users"}
+
+	resp, err := subscribeStream(t, settings, "ds/abc123/"+liveChannelPath(query.Query), query)
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, resp.Status)
+
+	resp, err = subscribeStream(t, settings, liveChannelPath("SELECT * FROM secrets"), nil)
+	require.Error(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, resp.Status)
+
+	resp, err = subscribeStream(t, settings, "firestore/users/0123abcd", query)
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusNotFound, resp.Status)
+
+	resp, err = subscribeStream(t, settings, "unknown", nil)
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusNotFound, resp.Status)
+}
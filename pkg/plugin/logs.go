@@ -0,0 +1,124 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// frameTypeLogLines is the dataplane type of log frames, which this SDK
+	// version does not define.
+	frameTypeLogLines data.FrameType = "log-lines"
+
+	logTimeField    = "time"
+	logBodyField    = "body"
+	logLevelField   = "level"
+	defaultLogField = "message"
+)
+
+// toLogFrame returns frame as a log frame for Grafana Explore: timeField,
+// messageField and levelField are renamed to time, body and level and moved
+// first, and the other fields are kept as is. timeField defaults to the
+// first time field of frame and messageField to "message". String times
+// must be RFC 3339 timestamps.
+func toLogFrame(frame *data.Frame, timeField, messageField, levelField string) (*data.Frame, error) {
+	if messageField == "" {
+		messageField = defaultLogField
+	}
+	if timeField == "" {
+		for _, f := range frame.Fields {
+			if f.Type().Time() {
+				timeField = f.Name
+				break
+			}
+		}
+		if timeField == "" {
+			return nil, fmt.Errorf("log mode requires a time field")
+		}
+	}
+
+	timeValues, err := logField(frame, timeField)
+	if err != nil {
+		return nil, err
+	}
+	times, err := logTimes(timeValues)
+	if err != nil {
+		return nil, err
+	}
+	body, err := logField(frame, messageField)
+	if err != nil {
+		return nil, err
+	}
+
+	out := data.NewFrame(frame.Name,
+		data.NewField(logTimeField, nil, times),
+		stringField(logBodyField, fieldValues(body)))
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	used := map[string]bool{timeField: true, messageField: true}
+	if levelField != "" {
+		level, err := logField(frame, levelField)
+		if err != nil {
+			return nil, err
+		}
+		out.Fields = append(out.Fields, stringField(logLevelField, fieldValues(level)))
+		used[levelField] = true
+	}
+	for _, f := range frame.Fields {
+		if !used[f.Name] {
+			out.Fields = append(out.Fields, f)
+		}
+	}
+
+	if out.Meta == nil {
+		out.Meta = &data.FrameMeta{}
+	}
+	out.Meta.Type = frameTypeLogLines
+	out.Meta.TypeVersion = data.FrameTypeVersion{0, 0}
+	out.Meta.PreferredVisualization = data.VisTypeLogs
+	return out, nil
+}
+
+func logField(frame *data.Frame, name string) (*data.Field, error) {
+	field, _ := frame.FieldByName(name)
+	if field == nil {
+		return nil, fmt.Errorf("log field %q not found", name)
+	}
+	return field, nil
+}
+
+// logTimes returns the values of a time or RFC 3339 string field as times.
+func logTimes(field *data.Field) ([]*time.Time, error) {
+	times := make([]*time.Time, field.Len())
+	for i := range times {
+		v, ok := field.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case time.Time:
+			times[i] = &v
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, fmt.Errorf("log time field %q: %v", field.Name, err)
+			}
+			times[i] = &t
+		default:
+			return nil, fmt.Errorf("log time field %q must hold times, got %T", field.Name, v)
+		}
+	}
+	return times, nil
+}
+
+func fieldValues(field *data.Field) []interface{} {
+	values := make([]interface{}, field.Len())
+	for i := range values {
+		if v, ok := field.ConcreteAt(i); ok {
+			values[i] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToLogFrame(t *testing.T) {
+	ts := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	msg, level := "started", "info"
+	frame := data.NewFrame("logs",
+		data.NewField("service", nil, []*string{&level}),
+		data.NewField("msg", nil, []*string{&msg}),
+		data.NewField("severity", nil, []*string{&level}),
+		data.NewField("created", nil, []*time.Time{&ts}),
+	)
+	frame.RefID = "A"
+
+	logs, err := toLogFrame(frame, "", "msg", "severity")
+	require.NoError(t, err)
+	require.Equal(t, "A", logs.RefID)
+	require.Equal(t, frameTypeLogLines, logs.Meta.Type)
+	require.Equal(t, data.VisTypeLogs, string(logs.Meta.PreferredVisualization))
+	names := make([]string, len(logs.Fields))
+	for i, f := range logs.Fields {
+		names[i] = f.Name
+	}
+	require.Equal(t, []string{"time", "body", "level", "service"}, names)
+	require.Equal(t, ts, *logs.Fields[0].At(0).(*time.Time))
+	require.Equal(t, "started", *logs.Fields[1].At(0).(*string))
+}
+
+func TestToLogFrameStringTimes(t *testing.T) {
+	created := "2024-03-15T10:30:00Z"
+	msg := "started"
+	frame := data.NewFrame("logs",
+		data.NewField("created", nil, []*string{&created, nil}),
+		data.NewField("message", nil, []*string{&msg, nil}),
+	)
+	logs, err := toLogFrame(frame, "created", "", "")
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeNullableTime, logs.Fields[0].Type())
+	require.Nil(t, logs.Fields[0].At(1))
+	require.Nil(t, logs.Fields[1].At(1))
+
+	invalid := "yesterday"
+	frame.Fields[0] = data.NewField("created", nil, []*string{&invalid, nil})
+	_, err = toLogFrame(frame, "created", "", "")
+	require.Error(t, err)
+	_, err = toLogFrame(frame, "", "", "")
+	require.Error(t, err)
+	_, err = toLogFrame(frame, "created", "body", "")
+	require.Error(t, err)
+}
+
+func TestLogModeQuery(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"timestamp", "message", "level"},
+		Records: [][]interface{}{{time.Now(), "started", "info"}},
+	}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select timestamp, message, level from logs", "logMode": true, "levelField": "level"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, frameTypeLogLines, resp.Frames[0].Meta.Type)
+	body, _ := resp.Frames[0].FieldByName("body")
+	require.NotNil(t, body)
+}
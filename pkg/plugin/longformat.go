@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// isLongFormat reports whether the frame has exactly one time field, one
+// string field and one numeric field, ignoring the fields injected by the
+// plugin.
+func isLongFormat(frame *data.Frame) bool {
+	var times, strs, numbers int
+	for _, field := range frame.Fields {
+		if strings.HasPrefix(field.Name, reservedFieldPrefix) {
+			continue
+		}
+		switch fieldType := field.Type(); {
+		case fieldType.Time():
+			times++
+		case fieldType.Numeric():
+			numbers++
+		case fieldType == data.FieldTypeString || fieldType == data.FieldTypeNullableString:
+			strs++
+		default:
+			return false
+		}
+	}
+	return times == 1 && strs == 1 && numbers == 1
+}
+
+// markLongFormat sets the frame type to long time series when the frame
+// matches the long format.
+func markLongFormat(frame *data.Frame) {
+	if !isLongFormat(frame) {
+		return
+	}
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	frame.Meta.Type = data.FrameTypeTimeSeriesLong
+}
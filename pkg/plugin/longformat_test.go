@@ -0,0 +1,32 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func newLongFormatFrame() *data.Frame {
+	now := time.Now()
+	metric := "cpu"
+	value := 0.5
+	return data.NewFrame("response",
+		data.NewField(documentIDField, nil, []*string{&metric}),
+		data.NewField("time", nil, []*time.Time{&now}),
+		data.NewField("metric", nil, []*string{&metric}),
+		data.NewField("value", nil, []*float64{&value}),
+	)
+}
+
+func TestMarkLongFormat(t *testing.T) {
+	frame := newLongFormatFrame()
+	markLongFormat(frame)
+	require.Equal(t, data.FrameTypeTimeSeriesLong, frame.Meta.Type)
+
+	frame = newLongFormatFrame()
+	frame.Fields = append(frame.Fields, data.NewField("host", nil, []*string{nil}))
+	markLongFormat(frame)
+	require.Nil(t, frame.Meta)
+}
@@ -0,0 +1,35 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var macroRegex = regexp.MustCompile(`\$__(timeFilter|from|to)\(\s*([^()\s]+)\s*\)`)
+
+// expandMacros replaces the time range macros of query with conditions on
+// the given field: $__timeFilter(field) restricts it to the time range,
+// $__from(field) to values after its start and $__to(field) to values before
+// its end. Bounds are inclusive and keep sub-second precision.
+func expandMacros(query string, tr backend.TimeRange) string {
+	return macroRegex.ReplaceAllStringFunc(query, func(macro string) string {
+		m := macroRegex.FindStringSubmatch(macro)
+		field := m[2]
+		from := fmt.Sprintf("%s >= %s", field, timestampLiteral(tr.From))
+		to := fmt.Sprintf("%s <= %s", field, timestampLiteral(tr.To))
+		switch m[1] {
+		case "from":
+			return from
+		case "to":
+			return to
+		}
+		return from + " AND " + to
+	})
+}
+
+func timestampLiteral(t time.Time) string {
+	return fmt.Sprintf("TIMESTAMP('%s')", t.UTC().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,24 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandMacros(t *testing.T) {
+	tr := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 1, 1, 0, 0, 250000000, time.UTC),
+	}
+
+	require.Equal(t,
+		"SELECT * FROM events WHERE createdAt >= TIMESTAMP('2024-01-01T00:00:00Z') AND createdAt <= TIMESTAMP('2024-01-01T01:00:00.25Z')",
+		expandMacros("SELECT * FROM events WHERE $__timeFilter(createdAt)", tr))
+	require.Equal(t, "ts >= TIMESTAMP('2024-01-01T00:00:00Z')", expandMacros("$__from(ts)", tr))
+	require.Equal(t, "ts <= TIMESTAMP('2024-01-01T01:00:00.25Z')", expandMacros("$__to( ts )", tr))
+	require.Equal(t, "", expandMacros("", tr))
+	require.Equal(t, "SELECT * FROM events", expandMacros("SELECT * FROM events", tr))
+}
@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/xwb1989/sqlparser"
+)
+
+// limitQuery returns the FireQL query with its LIMIT set to n.
+func limitQuery(query string, n int) (string, error) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return "", err
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return "", errors.New("only SELECT statements are supported")
+	}
+	sel.Limit = &sqlparser.Limit{Rowcount: sqlparser.NewIntVal([]byte(strconv.Itoa(n)))}
+	return sqlparser.String(sel), nil
+}
+
+// newSchemaFrame returns a frame without rows whose fields have the names of
+// the result columns and the types inferred from the result records. Columns
+// without any value are typed as strings.
+func newSchemaFrame(result *util.QueryResult) (*data.Frame, error) {
+	frame := data.NewFrame("response", data.NewField(documentIDField, nil, []*string{}))
+	for i, name := range result.Columns {
+		values := make([]interface{}, len(result.Records))
+		allNil := true
+		for j, record := range result.Records {
+			if i < len(record) {
+				values[j] = record[i]
+				allNil = allNil && record[i] == nil
+			}
+		}
+
+		fieldType := data.FieldTypeNullableString
+		if !allNil {
+			field, err := createTypedField(name, values, len(values))
+			if err != nil {
+				return nil, err
+			}
+			fieldType = field.Type()
+		}
+		field := data.NewFieldFromFieldType(fieldType, 0)
+		field.Name = name
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame, nil
+}
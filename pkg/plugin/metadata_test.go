@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryMetadataOnly(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"id", "name", "score", "active", "created", "deleted"},
+		Records: [][]interface{}{{int64(1), "Ada", 9.5, true, time.Now(), nil}},
+	}}}
+
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select * from users where age > 30 limit 50", "metadataOnly": true}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, []string{"select * from users where age > 30 limit 1"}, mock.queries)
+
+	require.Len(t, resp.Frames, 1)
+	frame := resp.Frames[0]
+	require.Equal(t, 0, frame.Rows())
+	expected := []struct {
+		name      string
+		fieldType data.FieldType
+	}{
+		{documentIDField, data.FieldTypeNullableString},
+		{"id", data.FieldTypeNullableInt64},
+		{"name", data.FieldTypeNullableString},
+		{"score", data.FieldTypeNullableFloat64},
+		{"active", data.FieldTypeNullableBool},
+		{"created", data.FieldTypeNullableTime},
+		{"deleted", data.FieldTypeNullableString},
+	}
+	require.Len(t, frame.Fields, len(expected))
+	for i, e := range expected {
+		require.Equal(t, e.name, frame.Fields[i].Name)
+		require.Equal(t, e.fieldType, frame.Fields[i].Type(), e.name)
+	}
+}
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// NaN handling modes for FirestoreQuery.NaNHandling.
+const (
+	nanHandlingNil    = "nil"
+	nanHandlingZero   = "zero"
+	nanHandlingString = "string"
+)
+
+// handleNonFiniteFloats replaces the NaN and Inf float values of result
+// according to mode: "nil" drops them, "zero" replaces them with 0 and
+// "string" replaces them with "NaN", "+Inf" or "-Inf", which turns their
+// column into a mixed column. An empty mode leaves the values untouched.
+func handleNonFiniteFloats(result *util.QueryResult, mode string) error {
+	switch mode {
+	case "":
+		return nil
+	case nanHandlingNil, nanHandlingZero, nanHandlingString:
+	default:
+		return fmt.Errorf("unknown NaN handling %q", mode)
+	}
+
+	for _, record := range result.Records {
+		for i, value := range record {
+			var f float64
+			switch v := value.(type) {
+			case float64:
+				f = v
+			case float32:
+				f = float64(v)
+			default:
+				continue
+			}
+			if !math.IsNaN(f) && !math.IsInf(f, 0) {
+				continue
+			}
+
+			switch mode {
+			case nanHandlingNil:
+				record[i] = nil
+			case nanHandlingZero:
+				record[i] = float64(0)
+			case nanHandlingString:
+				record[i] = strconv.FormatFloat(f, 'g', -1, 64)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newNaNTestResult() *util.QueryResult {
+	return &util.QueryResult{
+		Columns: []string{"value"},
+		Records: [][]interface{}{{1.5}, {math.NaN()}, {math.Inf(1)}, {math.Inf(-1)}},
+	}
+}
+
+func TestHandleNonFiniteFloats(t *testing.T) {
+	for _, tc := range []struct {
+		mode     string
+		expected []interface{}
+	}{
+		{mode: "nil", expected: []interface{}{1.5, nil, nil, nil}},
+		{mode: "zero", expected: []interface{}{1.5, 0.0, 0.0, 0.0}},
+		{mode: "string", expected: []interface{}{1.5, "NaN", "+Inf", "-Inf"}},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			result := newNaNTestResult()
+			require.NoError(t, handleNonFiniteFloats(result, tc.mode))
+
+			values := make([]interface{}, len(result.Records))
+			for i, record := range result.Records {
+				values[i] = record[0]
+			}
+			require.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func TestHandleNonFiniteFloatsUnknownMode(t *testing.T) {
+	require.Error(t, handleNonFiniteFloats(newNaNTestResult(), "drop"))
+
+	result := newNaNTestResult()
+	require.NoError(t, handleNonFiniteFloats(result, ""))
+	require.True(t, math.IsNaN(result.Records[1][0].(float64)))
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+const (
+	defaultMaxFlattenDepth      = 3
+	defaultNestedFieldSeparator = "."
+)
+
+// nestedPath is a node of the tree of map keys found in a column.
+type nestedPath struct {
+	keys     []string
+	children map[string]*nestedPath
+	// leaf is set when some row holds a value other than a map at the path.
+	leaf bool
+}
+
+func (p *nestedPath) add(value interface{}, depth, maxDepth int) {
+	m, ok := value.(map[string]interface{})
+	if !ok || depth >= maxDepth {
+		p.leaf = p.leaf || value != nil
+		return
+	}
+	if p.children == nil {
+		p.children = map[string]*nestedPath{}
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		child, ok := p.children[key]
+		if !ok {
+			child = &nestedPath{}
+			p.children[key] = child
+			p.keys = append(p.keys, key)
+		}
+		child.add(m[key], depth+1, maxDepth)
+	}
+}
+
+// flattenedColumn is a column of the flattened result, holding the value at
+// path within the values of a column of the original result.
+type flattenedColumn struct {
+	column   int
+	path     []string
+	expanded bool
+}
+
+func (p *nestedPath) columns(column int, path []string, out []flattenedColumn) []flattenedColumn {
+	if p.leaf || len(p.keys) == 0 {
+		out = append(out, flattenedColumn{column: column, path: path, expanded: len(p.keys) > 0})
+	}
+	for _, key := range p.keys {
+		out = p.children[key].columns(column, append(path[:len(path):len(path)], key), out)
+	}
+	return out
+}
+
+// value returns the value at the path of c in record, or nil when any level
+// of the path is missing or nil.
+func (c flattenedColumn) value(record []interface{}) interface{} {
+	if c.column >= len(record) {
+		return nil
+	}
+	value := record[c.column]
+	for _, key := range c.path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	if _, ok := value.(map[string]interface{}); ok && c.expanded {
+		return nil
+	}
+	return value
+}
+
+// flattenNestedFields expands the map values of result into one column per
+// leaf field, named by joining the keys of its path with separator (default
+// "."), e.g. "address.city". Maps nested deeper than maxDepth (default 3)
+// levels are kept as is. Rows missing a level of the path get nil.
+func flattenNestedFields(result *util.QueryResult, maxDepth int, separator string) {
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxFlattenDepth
+	}
+	if separator == "" {
+		separator = defaultNestedFieldSeparator
+	}
+
+	var columns []flattenedColumn
+	var names []string
+	nested := false
+	for column, name := range result.Columns {
+		root := &nestedPath{}
+		for _, record := range result.Records {
+			if column < len(record) {
+				root.add(record[column], 0, maxDepth)
+			}
+		}
+		nested = nested || len(root.keys) > 0
+		for _, c := range root.columns(column, nil, nil) {
+			columns = append(columns, c)
+			names = append(names, strings.Join(append([]string{name}, c.path...), separator))
+		}
+	}
+	if !nested {
+		return
+	}
+
+	for i, record := range result.Records {
+		flattened := make([]interface{}, len(columns))
+		for j, c := range columns {
+			flattened[j] = c.value(record)
+		}
+		result.Records[i] = flattened
+	}
+	result.Columns = names
+}
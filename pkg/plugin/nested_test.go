@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenNestedFields(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"name", "address"},
+		Records: [][]interface{}{
+			{"a", map[string]interface{}{"city": "Berlin", "zip": "10115", "geo": map[string]interface{}{"lat": 52.5}}},
+			{"b", map[string]interface{}{"city": "Paris", "geo": nil}},
+			{"c", nil},
+		},
+	}
+	flattenNestedFields(result, 0, "")
+	require.Equal(t, []string{"name", "address.city", "address.geo.lat", "address.zip"}, result.Columns)
+	require.Equal(t, [][]interface{}{
+		{"a", "Berlin", 52.5, "10115"},
+		{"b", "Paris", nil, nil},
+		{"c", nil, nil, nil},
+	}, result.Records)
+}
+
+func TestFlattenNestedFieldsDepthAndSeparator(t *testing.T) {
+	deep := map[string]interface{}{"c": map[string]interface{}{"d": 1}}
+	result := &util.QueryResult{
+		Columns: []string{"a"},
+		Records: [][]interface{}{{map[string]interface{}{"b": deep}}, {"scalar"}},
+	}
+	flattenNestedFields(result, 1, "_")
+	require.Equal(t, []string{"a", "a_b"}, result.Columns)
+	require.Equal(t, [][]interface{}{{nil, deep}, {"scalar", nil}}, result.Records)
+}
+
+func TestFlattenNestedFieldsWithoutMaps(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"id"}, Records: [][]interface{}{{1}}}
+	flattenNestedFields(result, 0, "")
+	require.Equal(t, []string{"id"}, result.Columns)
+	require.Equal(t, [][]interface{}{{1}}, result.Records)
+}
+
+func TestQueryFlattenNestedFields(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"address"},
+		Records: [][]interface{}{{map[string]interface{}{"city": "Berlin"}}},
+	}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select address from users", "flattenNestedFields": true}`)
+	require.NoError(t, resp.Error)
+	field, _ := resp.Frames[0].FieldByName("address.city")
+	require.NotNil(t, field)
+	require.Equal(t, "Berlin", *field.At(0).(*string))
+}
@@ -0,0 +1,133 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// OrderedMap is a JSON object that keeps its keys in the order they appear in
+// the document, so that fields derived from it have a stable order. Nested
+// objects are decoded as OrderedMap values too.
+type OrderedMap []OrderedMapEntry
+
+type OrderedMapEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// Get returns the value stored under key.
+func (m OrderedMap) Get(key string) (interface{}, bool) {
+	for _, entry := range m {
+		if entry.Key == key {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Keys returns the keys of the map in order.
+func (m OrderedMap) Keys() []string {
+	keys := make([]string, len(m))
+	for i, entry := range m {
+		keys[i] = entry.Key
+	}
+	return keys
+}
+
+func (m OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(entry.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (m *OrderedMap) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return errors.New("OrderedMap: expected a JSON object")
+	}
+	object, err := decodeOrderedObject(dec)
+	if err != nil {
+		return err
+	}
+	*m = object
+	return nil
+}
+
+// decodeOrderedObject decodes the members of an object whose opening brace
+// has already been read.
+func decodeOrderedObject(dec *json.Decoder) (OrderedMap, error) {
+	m := OrderedMap{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("OrderedMap: unexpected key %v", tok)
+		}
+		value, err := decodeOrderedValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, OrderedMapEntry{Key: key, Value: value})
+	}
+	// Closing brace
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		return decodeOrderedObject(dec)
+	case json.Delim('['):
+		values := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		// Closing bracket
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+	if number, ok := tok.(json.Number); ok {
+		return number.Float64()
+	}
+	return tok, nil
+}
@@ -0,0 +1,41 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMapJSON(t *testing.T) {
+	var m OrderedMap
+	require.NoError(t, json.Unmarshal([]byte(`{"z": 1, "a": {"y": true, "b": null}, "m": ["x", {"k": 2.5}]}`), &m))
+	require.Equal(t, []string{"z", "a", "m"}, m.Keys())
+
+	nested, ok := m.Get("a")
+	require.True(t, ok)
+	require.Equal(t, []string{"y", "b"}, nested.(OrderedMap).Keys())
+
+	raw, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"z":1,"a":{"y":true,"b":null},"m":["x",{"k":2.5}]}`, string(raw))
+
+	require.Error(t, json.Unmarshal([]byte(`["z"]`), &m))
+}
+
+func TestFlattenPreservesKeyOrder(t *testing.T) {
+	first := `{"z": 1, "a": 2, "m": 3}`
+	second := `{"a": 4, "n": 5}`
+	frame := data.NewFrame("response", data.NewField("attrs", nil, []*string{&first, &second}))
+
+	frame, err := applyTransformers(frame, []FirestoreTransformer{{Type: "flatten"}}, "")
+	require.NoError(t, err)
+
+	var names []string
+	for _, field := range frame.Fields {
+		names = append(names, field.Name)
+	}
+	require.Equal(t, []string{"attrs.z", "attrs.a", "attrs.m", "attrs.n"}, names)
+	require.Equal(t, "4", *frame.Fields[1].At(1).(*string))
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// autoPaginationPageSize matches the default page size of the Firestore API.
+const autoPaginationPageSize = 300
+
+// pageFunc fetches up to limit records following cursor, which is nil for the
+// first page, and returns the cursor to continue from.
+type pageFunc func(ctx context.Context, cursor interface{}, limit int) (*util.QueryResult, interface{}, error)
+
+// paginate fetches pages until one is shorter than pageSize or maxRows records
+// have been collected. A maxRows of zero means no limit.
+func paginate(ctx context.Context, fetch pageFunc, pageSize, maxRows int) (*util.QueryResult, error) {
+	result := &util.QueryResult{}
+	var cursor interface{}
+	for {
+		limit := pageSize
+		if maxRows > 0 && maxRows-len(result.Records) < limit {
+			limit = maxRows - len(result.Records)
+		}
+		if limit <= 0 {
+			return result, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		page, next, err := fetch(ctx, cursor, limit)
+		if err != nil {
+			return nil, err
+		}
+		appendResult(result, page)
+		if len(page.Records) < limit {
+			return result, nil
+		}
+		cursor = next
+	}
+}
+
+// appendResult appends the records of src to dst, adding any columns dst does
+// not have yet. Records are padded with nil for columns they lack.
+func appendResult(dst, src *util.QueryResult) {
+	index := make(map[string]int, len(dst.Columns))
+	for i, column := range dst.Columns {
+		index[column] = i
+	}
+	positions := make([]int, len(src.Columns))
+	for i, column := range src.Columns {
+		pos, ok := index[column]
+		if !ok {
+			pos = len(dst.Columns)
+			index[column] = pos
+			dst.Columns = append(dst.Columns, column)
+		}
+		positions[i] = pos
+	}
+
+	for i, record := range dst.Records {
+		for len(record) < len(dst.Columns) {
+			record = append(record, nil)
+		}
+		dst.Records[i] = record
+	}
+	for _, record := range src.Records {
+		row := make([]interface{}, len(dst.Columns))
+		for i, value := range record {
+			if i < len(positions) {
+				row[positions[i]] = value
+			}
+		}
+		dst.Records = append(dst.Records, row)
+	}
+}
+
+// paginationLimit returns the number of records to paginate through: maxRows,
+// capped by the LIMIT of the query, if any, and by the MaxDocuments setting,
+// as the documents beyond it would be read and billed only to be dropped.
+func paginationLimit(stmtLimit, maxRows int, settings FirestoreSettings) int {
+	if stmtLimit > 0 && (maxRows <= 0 || stmtLimit < maxRows) {
+		maxRows = stmtLimit
+	}
+	if limit := maxDocuments(settings); maxRows <= 0 || maxRows > limit {
+		maxRows = limit
+	}
+	return maxRows
+}
+
+// paginateQuery runs query directly against Firestore, fetching every page
+// with StartAfter cursors up to the paginationLimit.
+func (d *Datasource) paginateQuery(ctx context.Context, pCtx backend.PluginContext, settings FirestoreSettings, query string, maxRows int) (*util.QueryResult, error) {
+	stmt, err := parseSelectStatement(query)
+	if err != nil {
+		return nil, err
+	}
+	maxRows = paginationLimit(stmt.Limit, maxRows, settings)
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	unlimited := *stmt
+	unlimited.Limit = 0
+	fetch := func(ctx context.Context, cursor interface{}, limit int) (*util.QueryResult, interface{}, error) {
+		q, err := unlimited.firestoreQuery(client)
+		if err != nil {
+			return nil, nil, err
+		}
+		q = q.Limit(limit)
+		if cursor != nil {
+			q = q.StartAfter(cursor.(*firestore.DocumentSnapshot))
+		}
+		docs, err := q.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, nil, err
+		}
+		var next interface{}
+		if len(docs) > 0 {
+			next = docs[len(docs)-1]
+		}
+		return stmt.result(docs), next, nil
+	}
+	return paginate(ctx, fetch, autoPaginationPageSize, maxRows)
+}
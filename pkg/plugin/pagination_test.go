@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedRecords returns a pageFunc serving total single-column records, using
+// the offset of the next record as the cursor.
+func pagedRecords(total int, limits *[]int) pageFunc {
+	return func(ctx context.Context, cursor interface{}, limit int) (*util.QueryResult, interface{}, error) {
+		*limits = append(*limits, limit)
+		offset := 0
+		if cursor != nil {
+			offset = cursor.(int)
+		}
+		page := &util.QueryResult{Columns: []string{"n"}}
+		for i := offset; i < total && i < offset+limit; i++ {
+			page.Records = append(page.Records, []interface{}{i})
+		}
+		return page, offset + len(page.Records), nil
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	var limits []int
+	result, err := paginate(context.Background(), pagedRecords(450, &limits), autoPaginationPageSize, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Records, 450)
+	require.Equal(t, []int{300, 300}, limits)
+	require.Equal(t, 449, result.Records[449][0])
+
+	limits = nil
+	result, err = paginate(context.Background(), pagedRecords(450, &limits), autoPaginationPageSize, 400)
+	require.NoError(t, err)
+	require.Len(t, result.Records, 400)
+	require.Equal(t, []int{300, 100}, limits)
+}
+
+func TestAppendResultMergesColumns(t *testing.T) {
+	result := &util.QueryResult{}
+	appendResult(result, &util.QueryResult{Columns: []string{"a", "b"}, Records: [][]interface{}{{1, 2}}})
+	appendResult(result, &util.QueryResult{Columns: []string{"c", "a"}, Records: [][]interface{}{{3, 4}}})
+	require.Equal(t, []string{"a", "b", "c"}, result.Columns)
+	require.Equal(t, [][]interface{}{{1, 2, nil}, {4, nil, 3}}, result.Records)
+}
+
+func TestPaginationLimit(t *testing.T) {
+	settings := FirestoreSettings{}
+	require.Equal(t, defaultMaxDocuments, paginationLimit(0, 0, settings))
+	require.Equal(t, 400, paginationLimit(0, 400, settings))
+	require.Equal(t, 50, paginationLimit(50, 400, settings))
+	require.Equal(t, 50, paginationLimit(50, 0, settings))
+	require.Equal(t, defaultMaxDocuments, paginationLimit(0, 50000, settings))
+
+	settings.MaxDocuments = 500
+	require.Equal(t, 500, paginationLimit(0, 0, settings))
+	require.Equal(t, 500, paginationLimit(1000, 2000, settings))
+
+	// Pagination stops once the limit is reached.
+	var limits []int
+	result, err := paginate(context.Background(), pagedRecords(100000, &limits), autoPaginationPageSize, paginationLimit(0, 0, settings))
+	require.NoError(t, err)
+	require.Len(t, result.Records, 500)
+	require.Equal(t, []int{300, 200}, limits)
+}
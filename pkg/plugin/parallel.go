@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// defaultMaxParallelQueries caps the queries of a request run concurrently
+// when FirestoreSettings.MaxParallelQueries is not set.
+const defaultMaxParallelQueries = 5
+
+// runParallel runs the queries concurrently, at most limit at a time, and
+// returns their responses by RefID.
+func runParallel(queries []backend.DataQuery, limit int, run func(backend.DataQuery) backend.DataResponse) backend.Responses {
+	if limit <= 0 {
+		limit = defaultMaxParallelQueries
+	}
+
+	responses := make(backend.Responses, len(queries))
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, limit)
+	)
+	for _, q := range queries {
+		q := q
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			res := run(q)
+			mu.Lock()
+			responses[q.RefID] = res
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return responses
+}
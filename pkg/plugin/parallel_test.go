@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyExecutor records the maximum number of queries it executed
+// concurrently.
+type concurrencyExecutor struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (e *concurrencyExecutor) Execute(query string) (*util.QueryResult, error) {
+	e.mu.Lock()
+	e.running++
+	e.max = max(e.max, e.running)
+	e.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	return &util.QueryResult{Columns: []string{"query"}, Records: [][]interface{}{{query}}}, nil
+}
+
+func TestParallelQueries(t *testing.T) {
+	executor := &concurrencyExecutor{}
+	ds := &Datasource{newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+		return executor, nil
+	}}
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test", MaxParallelQueries: 3})
+	require.NoError(t, err)
+
+	var queries []backend.DataQuery
+	for i := 0; i < 8; i++ {
+		queries = append(queries, backend.DataQuery{
+			RefID: fmt.Sprintf("Q%d", i),
+			JSON:  []byte(fmt.Sprintf(`{"query": "select id from c%d"}`, i)),
+		})
+	}
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Queries: queries,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, 8)
+	for i := 0; i < 8; i++ {
+		res := resp.Responses[fmt.Sprintf("Q%d", i)]
+		require.NoError(t, res.Error)
+		field, _ := res.Frames[0].FieldByName("query")
+		require.Equal(t, fmt.Sprintf("select id from c%d", i), *field.At(0).(*string))
+	}
+	require.Equal(t, 3, executor.max)
+}
@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xwb1989/sqlparser"
+)
+
+var parameterNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// bindParameters replaces the @name placeholders of query with the SQL literal
+// of the matching parameter. Strings are single-quoted and escaped, numbers
+// and booleans are inserted bare. Placeholders inside quoted strings and
+// identifiers are left untouched.
+func bindParameters(query string, params map[string]interface{}) (string, error) {
+	literals := make(map[string]string, len(params))
+	for name, value := range params {
+		if !parameterNameRegex.MatchString(name) {
+			return "", fmt.Errorf("invalid parameter name %q", name)
+		}
+		literal, err := parameterLiteral(value)
+		if err != nil {
+			return "", fmt.Errorf("parameter %q: %v", name, err)
+		}
+		literals[name] = literal
+	}
+
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(query) {
+				i++
+				b.WriteByte(query[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			b.WriteByte(c)
+		case c == '@':
+			end := i + 1
+			for end < len(query) && isParameterNameChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			if name == "" {
+				b.WriteByte(c)
+				continue
+			}
+			literal, ok := literals[name]
+			if !ok {
+				return "", fmt.Errorf("missing value for parameter @%s", name)
+			}
+			b.WriteString(literal)
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func isParameterNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func parameterLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return sqlparser.String(sqlparser.NewStrVal([]byte(v))), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	}
+	return "", fmt.Errorf("unsupported value type %T", value)
+}
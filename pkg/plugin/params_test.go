@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xwb1989/sqlparser"
+)
+
+func TestBindParameters(t *testing.T) {
+	query, err := bindParameters(
+		"select * from orders where status = @status and total > @total and email != 'ops@example.com' and paid = @paid",
+		map[string]interface{}{"status": "it's shipped", "total": float64(10.5), "paid": true},
+	)
+	require.NoError(t, err)
+	require.Equal(t, `select * from orders where status = 'it\'s shipped' and total > 10.5 and email != 'ops@example.com' and paid = true`, query)
+
+	stmt, err := parseSelectStatement(query)
+	require.NoError(t, err)
+	require.Equal(t, "it's shipped", stmt.Filters[0].Value)
+}
+
+func TestBindParametersInjection(t *testing.T) {
+	query, err := bindParameters("select * from orders where status = @status",
+		map[string]interface{}{"status": `x\' or '1'='1`})
+	require.NoError(t, err)
+
+	stmt, err := sqlparser.Parse(query)
+	require.NoError(t, err)
+	where := stmt.(*sqlparser.Select).Where.Expr.(*sqlparser.ComparisonExpr)
+	require.Equal(t, `x\' or '1'='1`, string(where.Right.(*sqlparser.SQLVal).Val))
+}
+
+func TestBindParametersErrors(t *testing.T) {
+	_, err := bindParameters("select * from orders where status = @status", map[string]interface{}{})
+	require.Error(t, err)
+
+	_, err = bindParameters("select * from orders", map[string]interface{}{"bad name": "x"})
+	require.Error(t, err)
+
+	_, err = bindParameters("select * from orders where tags = @tags", map[string]interface{}{"tags": []interface{}{"a"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+const (
+	defaultPollingInterval = 30 * time.Second
+	// pollerIdleIntervals is the number of intervals a poller keeps running
+	// without its result being read.
+	pollerIdleIntervals = 10
+)
+
+// pollers re-executes queries in the background so that their results are
+// ready when Grafana asks for them. The zero value is ready to use.
+type pollers struct {
+	mu      sync.Mutex
+	entries map[string]*poller
+	wg      sync.WaitGroup
+	// stopped is set by stopAll; no pollers are started afterwards.
+	stopped bool
+}
+
+type poller struct {
+	cancel   context.CancelFunc
+	result   *util.QueryResult
+	lastRead time.Time
+}
+
+// get returns the latest result polled for key, if any.
+func (p *pollers) get(key string) (*util.QueryResult, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[key]
+	if !ok || entry.result == nil {
+		return nil, false
+	}
+	entry.lastRead = time.Now()
+	return copyResult(entry.result), true
+}
+
+// start polls run every interval, storing its result under key. When a
+// poller already runs for key, only its result is replaced. The poller stops
+// when its result has not been read for pollerIdleIntervals intervals.
+// Results are stored as copies, as callers modify the ones they get.
+func (p *pollers) start(key string, interval time.Duration, result *util.QueryResult, run func(ctx context.Context) (*util.QueryResult, error)) {
+	result = copyResult(result)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	if entry, ok := p.entries[key]; ok {
+		entry.result = result
+		entry.lastRead = time.Now()
+		return
+	}
+	if p.entries == nil {
+		p.entries = map[string]*poller{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entry := &poller{cancel: cancel, result: result, lastRead: time.Now()}
+	p.entries[key] = entry
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			p.mu.Lock()
+			idle := time.Since(entry.lastRead) > pollerIdleIntervals*interval
+			if idle {
+				delete(p.entries, key)
+			}
+			p.mu.Unlock()
+			if idle {
+				log.DefaultLogger.Debug("Stopping idle background poller", "query", key)
+				cancel()
+				return
+			}
+
+			polled, err := run(ctx)
+			if err != nil {
+				log.DefaultLogger.Warn("Background polling failed", "query", key, "error", err)
+				continue
+			}
+			p.mu.Lock()
+			entry.result = polled
+			p.mu.Unlock()
+		}
+	}()
+}
+
+// stopAll stops every poller and waits for them to return. No pollers are
+// started afterwards.
+func (p *pollers) stopAll() {
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	p.clear()
+}
+
+// clear stops every poller, forgetting their results, and waits for them to return.
+func (p *pollers) clear() {
+	p.mu.Lock()
+	for _, entry := range p.entries {
+		entry.cancel()
+	}
+	p.entries = nil
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// pollQuery returns the latest polled result of the query, executing it and
+// starting a background poller when there is none yet. With reload, the
+// query is executed even when a polled result is available.
+//
+// prepare returns qm.Query over a time range. A time range ending less than
+// an interval ago is taken as relative to now: its queries are keyed by the
+// duration of the range rather than by their time filters, which change on
+// every refresh, and the poller slides the range up to the time of each run.
+func (d *Datasource) pollQuery(ctx context.Context, pCtx backend.PluginContext, executor queryExecutor, qm FirestoreQuery, settings FirestoreSettings, reload bool, tr backend.TimeRange, prepare func(backend.TimeRange) (string, error)) (*util.QueryResult, error) {
+	interval := defaultPollingInterval
+	if qm.PollingIntervalSecs > 0 {
+		interval = time.Duration(qm.PollingIntervalSecs) * time.Second
+	}
+	query := func() (string, error) { return qm.Query, nil }
+	keyQuery := qm.Query
+	if time.Since(tr.To) < interval {
+		duration := tr.To.Sub(tr.From)
+		query = func() (string, error) {
+			now := time.Now()
+			return prepare(backend.TimeRange{From: now.Add(-duration), To: now})
+		}
+		var err error
+		if keyQuery, err = prepare(backend.TimeRange{To: time.Time{}.Add(duration)}); err != nil {
+			return nil, err
+		}
+	}
+
+	key := datasourceCacheKey(pCtx, settings, keyQuery)
+	if !reload {
+		if result, ok := d.pollers.get(key); ok {
+			return result, nil
+		}
+	}
+	result, err := executeQuery(ctx, executor, qm.Query, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	d.pollers.start(key, interval, result, func(ctx context.Context) (*util.QueryResult, error) {
+		query, err := query()
+		if err != nil {
+			return nil, err
+		}
+		return executeQuery(ctx, executor, query, settings)
+	})
+	return result, nil
+}
+
+// copyResult returns a copy of result whose columns and records can be
+// modified without changing result.
+func copyResult(result *util.QueryResult) *util.QueryResult {
+	if result == nil {
+		return nil
+	}
+	records := make([][]interface{}, len(result.Records))
+	for i, record := range result.Records {
+		records[i] = append([]interface{}(nil), record...)
+	}
+	return &util.QueryResult{
+		Columns: append([]string(nil), result.Columns...),
+		Records: records,
+	}
+}
+
+// forceReload reports whether a ForceReloadOnDashboardLoad query must bypass
+// the polled results: on the first call of its RefID since the query cache
+// was purged, or when its query type carries a "load" hint.
+func (d *Datasource) forceReload(query backend.DataQuery, qm FirestoreQuery) bool {
+	if !qm.ForceReloadOnDashboardLoad {
+		return false
+	}
+	_, loaded := d.loadedRefIDs.LoadOrStore(query.RefID, struct{}{})
+	return !loaded || strings.Contains(strings.ToLower(query.QueryType), "load")
+}
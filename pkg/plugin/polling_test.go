@@ -0,0 +1,141 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBackgroundPolling(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"status"}, Records: [][]interface{}{{"first"}}},
+		{Columns: []string{"status"}, Records: [][]interface{}{{"polled"}}},
+	}}
+	ds := newMockDatasource(mock)
+	query := `{"query": "select status from jobs", "backgroundPolling": true, "pollingIntervalSecs": 1}`
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, "first", *resp.Frames[0].Fields[1].At(0).(*string))
+
+	require.Eventually(t, func() bool { return mock.calls() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	calls := mock.calls()
+
+	// Served from the poller without executing the query
+	resp = runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, "polled", *resp.Frames[0].Fields[1].At(0).(*string))
+	require.Equal(t, calls, mock.calls())
+
+	ds.Dispose()
+	require.Empty(t, ds.pollers.entries)
+}
+
+func TestQueryForceReloadOnDashboardLoad(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"status"}, Records: [][]interface{}{{"first"}}},
+		{Columns: []string{"status"}, Records: [][]interface{}{{"reloaded"}}},
+	}}
+	ds := newMockDatasource(mock)
+	defer ds.Dispose()
+	settings := FirestoreSettings{ProjectId: "test"}
+	query := `{"query": "select status from jobs", "backgroundPolling": true, "pollingIntervalSecs": 3600, "forceReloadOnDashboardLoad": true}`
+
+	resp := runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 1, mock.calls())
+
+	// Served from the poller
+	resp = runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 1, mock.calls())
+
+	// The first call after the query cache is purged executes the query
+	cachePurgers["queries"](ds)
+	resp = runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, mock.calls())
+	require.Equal(t, "reloaded", *resp.Frames[0].Fields[1].At(0).(*string))
+
+	resp = runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, mock.calls())
+	require.Equal(t, "reloaded", *resp.Frames[0].Fields[1].At(0).(*string))
+}
+
+func TestQueryBackgroundPollingKeepsPolledResult(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"region", "revenue"}, Records: [][]interface{}{{"eu", int64(10)}, {"eu", int64(5)}}},
+	}}
+	ds := newMockDatasource(mock)
+	defer ds.Dispose()
+	query := `{"query": "select region, revenue from orders", "backgroundPolling": true, "pollingIntervalSecs": 3600,
+		"groupBy": "region", "aggregations": [{"field": "revenue", "function": "sum"}]}`
+
+	for i := 0; i < 2; i++ {
+		resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, query)
+		require.NoError(t, resp.Error)
+		sum, _ := resp.Frames[0].FieldByName("sum_revenue")
+		require.Equal(t, 15.0, *sum.At(0).(*float64))
+	}
+	require.Equal(t, 1, mock.calls())
+}
+
+func TestQueryBackgroundPollingPerDatabase(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"status"}, Records: [][]interface{}{{"default"}}},
+		{Columns: []string{"status"}, Records: [][]interface{}{{"other"}}},
+	}}
+	ds := newMockDatasource(mock)
+	defer ds.Dispose()
+	query := `{"query": "select status from jobs", "backgroundPolling": true, "pollingIntervalSecs": 3600}`
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, query)
+	require.Equal(t, "default", *resp.Frames[0].Fields[1].At(0).(*string))
+	resp = runQuery(t, ds, FirestoreSettings{ProjectId: "test", DatabaseName: "other"}, query)
+	require.Equal(t, "other", *resp.Frames[0].Fields[1].At(0).(*string))
+	require.Equal(t, 2, mock.calls())
+}
+
+func TestCopyResult(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"a"}, Records: [][]interface{}{{1}}}
+	copied := copyResult(result)
+	copied.Columns[0] = "b"
+	copied.Records[0][0] = 2
+	require.Equal(t, &util.QueryResult{Columns: []string{"a"}, Records: [][]interface{}{{1}}}, result)
+	require.Nil(t, copyResult(nil))
+}
+
+func TestQueryBackgroundPollingRelativeTimeRange(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"status"}, Records: [][]interface{}{{"first"}}},
+	}}
+	ds := newMockDatasource(mock)
+	defer ds.Dispose()
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+
+	// Refreshes of a "last hour" panel expand $__timeFilter differently but
+	// share a single poller
+	for i := 0; i < 3; i++ {
+		now := time.Now()
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings}},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON:      []byte(`{"query": "select status from jobs where $__timeFilter(created)", "backgroundPolling": true, "pollingIntervalSecs": 3600}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 1, mock.calls())
+	require.Len(t, ds.pollers.entries, 1)
+}
@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// projectFields removes the fields of frame named in exclude and, when
+// include is not empty, the fields it does not name. The fields injected by
+// the plugin, such as __document_id, are only removed when excluded.
+func projectFields(frame *data.Frame, include, exclude []string) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return
+	}
+	included := stringSet(include)
+	excluded := stringSet(exclude)
+
+	fields := frame.Fields[:0]
+	for _, field := range frame.Fields {
+		switch {
+		case excluded[field.Name]:
+		case len(included) > 0 && !included[field.Name] && !strings.HasPrefix(field.Name, reservedFieldPrefix):
+		default:
+			fields = append(fields, field)
+		}
+	}
+	frame.Fields = fields
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newProjectionTestFrame() *data.Frame {
+	return data.NewFrame("response",
+		data.NewField(documentIDField, nil, []*string{nil}),
+		data.NewField("name", nil, []*string{nil}),
+		data.NewField("_metadata", nil, []*string{nil}),
+		data.NewField("_version", nil, []*int64{nil}),
+	)
+}
+
+func frameFieldNames(frame *data.Frame) []string {
+	names := make([]string, len(frame.Fields))
+	for i, f := range frame.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func TestProjectFields(t *testing.T) {
+	frame := newProjectionTestFrame()
+	projectFields(frame, nil, []string{"_metadata", "_version"})
+	require.Equal(t, []string{documentIDField, "name"}, frameFieldNames(frame))
+
+	frame = newProjectionTestFrame()
+	projectFields(frame, []string{"name", "missing"}, nil)
+	require.Equal(t, []string{documentIDField, "name"}, frameFieldNames(frame))
+
+	frame = newProjectionTestFrame()
+	projectFields(frame, []string{"name"}, []string{documentIDField})
+	require.Equal(t, []string{"name"}, frameFieldNames(frame))
+
+	frame = newProjectionTestFrame()
+	projectFields(frame, nil, nil)
+	require.Len(t, frame.Fields, 4)
+}
+
+func TestProjectFieldsQuery(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"name", "_sync"},
+		Records: [][]interface{}{{"alice", true}},
+	}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select * from users", "excludeFields": ["_sync"]}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, []string{documentIDField, "name"}, frameFieldNames(resp.Frames[0]))
+}
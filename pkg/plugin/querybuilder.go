@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// Direction is the sort direction of a StructuredOrder.
+type Direction string
+
+const (
+	Ascending  Direction = "asc"
+	Descending Direction = "desc"
+)
+
+// StructuredQuery is a Firestore query described as JSON rather than as a
+// FireQL string. It is run directly against Firestore.
+type StructuredQuery struct {
+	Collection string
+	Where      []StructuredFilter
+	OrderBy    []StructuredOrder
+	Limit      int
+}
+
+type StructuredFilter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+type StructuredOrder struct {
+	Field     string
+	Direction Direction
+}
+
+// structuredOperators are the Firestore operators accepted in StructuredFilter.
+var structuredOperators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"in": true, "not-in": true, "array-contains": true, "array-contains-any": true,
+}
+
+// FirestoreQueryBuilder builds a FirestoreQuery holding a StructuredQuery:
+//
+//	qm, err := NewFirestoreQueryBuilder().From("orders").
+//		Where("status", "==", "shipped").
+//		OrderBy("created", Descending).
+//		Limit(10).
+//		Build()
+type FirestoreQueryBuilder struct {
+	query StructuredQuery
+}
+
+func NewFirestoreQueryBuilder() *FirestoreQueryBuilder {
+	return &FirestoreQueryBuilder{}
+}
+
+func (b *FirestoreQueryBuilder) From(collection string) *FirestoreQueryBuilder {
+	b.query.Collection = collection
+	return b
+}
+
+func (b *FirestoreQueryBuilder) Where(field, op string, value interface{}) *FirestoreQueryBuilder {
+	b.query.Where = append(b.query.Where, StructuredFilter{Field: field, Op: op, Value: value})
+	return b
+}
+
+func (b *FirestoreQueryBuilder) OrderBy(field string, dir Direction) *FirestoreQueryBuilder {
+	b.query.OrderBy = append(b.query.OrderBy, StructuredOrder{Field: field, Direction: dir})
+	return b
+}
+
+func (b *FirestoreQueryBuilder) Limit(n int) *FirestoreQueryBuilder {
+	b.query.Limit = n
+	return b
+}
+
+// Build validates the query and returns it as a FirestoreQuery.
+func (b *FirestoreQueryBuilder) Build() (FirestoreQuery, error) {
+	query := b.query
+	if _, err := query.statement(); err != nil {
+		return FirestoreQuery{}, err
+	}
+	return FirestoreQuery{StructuredQuery: &query}, nil
+}
+
+// statement validates the query and converts it to a selectStatement
+// selecting every field.
+func (q *StructuredQuery) statement() (*selectStatement, error) {
+	if q.Collection == "" {
+		return nil, errors.New("structured query: collection is required")
+	}
+	if q.Limit < 0 {
+		return nil, fmt.Errorf("structured query: invalid limit %d", q.Limit)
+	}
+
+	stmt := &selectStatement{Star: true, Collection: q.Collection, Limit: q.Limit}
+	for _, f := range q.Where {
+		if f.Field == "" {
+			return nil, errors.New("structured query: filter field is required")
+		}
+		if !structuredOperators[f.Op] {
+			return nil, fmt.Errorf("structured query: unsupported operator %q", f.Op)
+		}
+		stmt.Filters = append(stmt.Filters, statementFilter{Field: f.Field, Op: f.Op, Value: f.Value})
+	}
+	for _, o := range q.OrderBy {
+		if o.Field == "" {
+			return nil, errors.New("structured query: order field is required")
+		}
+		var direction firestore.Direction
+		switch o.Direction {
+		case Ascending, "":
+			direction = firestore.Asc
+		case Descending:
+			direction = firestore.Desc
+		default:
+			return nil, fmt.Errorf("structured query: unsupported direction %q", o.Direction)
+		}
+		stmt.OrderBy = append(stmt.OrderBy, statementOrder{Field: o.Field, Direction: direction})
+	}
+	return stmt, nil
+}
+
+// runStructuredQuery runs q, limited to the DefaultLimit setting when it has
+// no limit of its own.
+func (d *Datasource) runStructuredQuery(ctx context.Context, pCtx backend.PluginContext, settings FirestoreSettings, q *StructuredQuery) (*util.QueryResult, error) {
+	stmt, err := q.statement()
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Limit == 0 {
+		stmt.Limit = queryLimit(settings)
+	}
+	return d.runStatement(ctx, pCtx, stmt)
+}
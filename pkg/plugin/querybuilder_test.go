@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirestoreQueryBuilder(t *testing.T) {
+	qm, err := NewFirestoreQueryBuilder().From("orders").
+		Where("status", "==", "shipped").
+		Where("total", ">=", 100).
+		Where("tags", "array-contains", "priority").
+		OrderBy("total", Descending).
+		Limit(10).
+		Build()
+	require.NoError(t, err)
+	require.Empty(t, qm.Query)
+
+	// The built query survives the round trip through the query JSON
+	raw, err := json.Marshal(qm)
+	require.NoError(t, err)
+	var decoded FirestoreQuery
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.NotNil(t, decoded.StructuredQuery)
+
+	stmt, err := decoded.StructuredQuery.statement()
+	require.NoError(t, err)
+	require.Equal(t, &selectStatement{
+		Star:       true,
+		Collection: "orders",
+		Filters: []statementFilter{
+			{Field: "status", Op: "==", Value: "shipped"},
+			{Field: "total", Op: ">=", Value: float64(100)},
+			{Field: "tags", Op: "array-contains", Value: "priority"},
+		},
+		OrderBy: []statementOrder{{Field: "total", Direction: firestore.Desc}},
+		Limit:   10,
+	}, stmt)
+}
+
+func TestFirestoreQueryBuilderErrors(t *testing.T) {
+	_, err := NewFirestoreQueryBuilder().Where("status", "==", "shipped").Build()
+	require.Error(t, err)
+
+	_, err = NewFirestoreQueryBuilder().From("orders").Where("status", "like", "ship%").Build()
+	require.Error(t, err)
+
+	_, err = NewFirestoreQueryBuilder().From("orders").OrderBy("total", "sideways").Build()
+	require.Error(t, err)
+}
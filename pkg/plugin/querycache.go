@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// cachedResponseNotice marks the frames of responses served from the query
+// result cache.
+const cachedResponseNotice = "Served from the query result cache"
+
+type queryCacheEntry struct {
+	response backend.DataResponse
+	expires  time.Time
+}
+
+// cachedQuery returns the cached response of query while it is younger than
+// ttl, and runs and caches the query otherwise. Failed responses are not
+// cached, and expired entries are dropped whenever a response is cached. The cache belongs to the datasource instance, which is replaced
+// when the datasource settings change.
+func (d *Datasource) cachedQuery(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery, ttl time.Duration) backend.DataResponse {
+	key := querySignature(query)
+	if v, ok := d.queryCache.Load(key); ok {
+		entry := v.(*queryCacheEntry)
+		if time.Now().Before(entry.expires) {
+			response := copyResponse(entry.response)
+			for _, frame := range response.Frames {
+				frame.AppendNotices(data.Notice{Severity: data.NoticeSeverityInfo, Text: cachedResponseNotice})
+			}
+			return response
+		}
+		d.queryCache.CompareAndDelete(key, entry)
+	}
+
+	response := d.query(ctx, pCtx, query)
+	if response.Error == nil {
+		now := time.Now()
+		// Keys include the time range, so the entries of relative ranges are
+		// never looked up again once the range moved on
+		d.sweepQueryCache(now)
+		d.queryCache.Store(key, &queryCacheEntry{response: copyResponse(response), expires: now.Add(ttl)})
+	}
+	return response
+}
+
+// sweepQueryCache drops the entries of the query cache expired at now.
+func (d *Datasource) sweepQueryCache(now time.Time) {
+	d.queryCache.Range(func(key, v interface{}) bool {
+		if !now.Before(v.(*queryCacheEntry).expires) {
+			d.queryCache.CompareAndDelete(key, v)
+		}
+		return true
+	})
+}
+
+// copyResponse returns a copy of response whose frames can be modified
+// without affecting those of response.
+func copyResponse(response backend.DataResponse) backend.DataResponse {
+	copied := response
+	copied.Frames = make(data.Frames, len(response.Frames))
+	for i, frame := range response.Frames {
+		rows := make([]int, frame.Rows())
+		for row := range rows {
+			rows[row] = row
+		}
+		copied.Frames[i] = selectRows(frame, rows)
+		if frame.Meta != nil {
+			meta := *frame.Meta
+			meta.Notices = append([]data.Notice(nil), frame.Meta.Notices...)
+			copied.Frames[i].Meta = &meta
+		}
+	}
+	return copied
+}
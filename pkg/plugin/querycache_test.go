@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"id"}, Records: [][]interface{}{{"1"}}},
+		{Columns: []string{"id"}, Records: [][]interface{}{{"1"}, {"2"}}},
+	}}
+	ds := newMockDatasource(mock)
+	settings := FirestoreSettings{ProjectId: "test", CacheTTLSeconds: 60}
+
+	resp := runQuery(t, ds, settings, `{"query": "select id from users"}`)
+	require.NoError(t, resp.Error)
+	require.Empty(t, resp.Frames[0].Meta.Notices)
+
+	for i := 0; i < 2; i++ {
+		resp = runQuery(t, ds, settings, `{"query": "select id from users"}`)
+		require.NoError(t, resp.Error)
+		require.Equal(t, 1, resp.Frames[0].Rows())
+		require.Equal(t, []data.Notice{{Severity: data.NoticeSeverityInfo, Text: cachedResponseNotice}}, resp.Frames[0].Meta.Notices)
+	}
+	require.Equal(t, 1, mock.calls())
+
+	resp = runQuery(t, ds, settings, `{"query": "select id from users where id > 0"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, resp.Frames[0].Rows())
+	require.Equal(t, 2, mock.calls())
+
+	cachePurgers["queries"](ds)
+	runQuery(t, ds, settings, `{"query": "select id from users"}`)
+	require.Equal(t, 3, mock.calls())
+}
+
+func TestQueryCacheDisabled(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}, Records: [][]interface{}{{"1"}}}}}
+	ds := newMockDatasource(mock)
+
+	for i := 0; i < 2; i++ {
+		resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"}, `{"query": "select id from users"}`)
+		require.NoError(t, resp.Error)
+	}
+	require.Equal(t, 2, mock.calls())
+}
+
+func TestQueryCacheDropsExpiredEntries(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}, Records: [][]interface{}{{"1"}}}}}
+	ds := newMockDatasource(mock)
+	settings := FirestoreSettings{ProjectId: "test", CacheTTLSeconds: 60}
+
+	for _, query := range []string{`{"query": "select id from users"}`, `{"query": "select id from stores"}`} {
+		resp := runQuery(t, ds, settings, query)
+		require.NoError(t, resp.Error)
+	}
+	ds.queryCache.Range(func(_, v interface{}) bool {
+		v.(*queryCacheEntry).expires = time.Now().Add(-time.Second)
+		return true
+	})
+
+	resp := runQuery(t, ds, settings, `{"query": "select id from orders"}`)
+	require.NoError(t, resp.Error)
+	entries := 0
+	ds.queryCache.Range(func(_, _ interface{}) bool {
+		entries++
+		return true
+	})
+	require.Equal(t, 1, entries)
+}
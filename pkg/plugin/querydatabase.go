@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// databaseIDPattern matches valid Firestore database IDs: 4 to 63 lowercase
+// letters, digits or hyphens, starting with a letter and not ending with a
+// hyphen, or (default).
+var databaseIDPattern = regexp.MustCompile(`^([a-z][a-z0-9-]{2,61}[a-z0-9]|\(default\))$`)
+
+// withDatabaseName returns pCtx with its DatabaseName setting replaced by
+// name, so that the executors and Firestore clients created from it use
+// that database.
+func withDatabaseName(pCtx backend.PluginContext, name string) (backend.PluginContext, error) {
+	if !databaseIDPattern.MatchString(name) {
+		return pCtx, fmt.Errorf("invalid database name %q", name)
+	}
+
+	jsonData := map[string]interface{}{}
+	if err := json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &jsonData); err != nil {
+		return pCtx, fmt.Errorf("ProjectID: %v", err)
+	}
+	// Settings are matched case-insensitively, e.g. databaseName
+	for key := range jsonData {
+		if strings.EqualFold(key, "DatabaseName") {
+			delete(jsonData, key)
+		}
+	}
+	jsonData["DatabaseName"] = name
+	b, err := json.Marshal(jsonData)
+	if err != nil {
+		return pCtx, err
+	}
+
+	instanceSettings := *pCtx.DataSourceInstanceSettings
+	instanceSettings.JSONData = b
+	pCtx.DataSourceInstanceSettings = &instanceSettings
+	return pCtx, nil
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDatabaseName(t *testing.T) {
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"projectId": "test", "databaseName": "production", "maxDocuments": 5}`),
+	}}
+
+	staging, err := withDatabaseName(pCtx, "staging")
+	require.NoError(t, err)
+	var settings FirestoreSettings
+	require.NoError(t, json.Unmarshal(staging.DataSourceInstanceSettings.JSONData, &settings))
+	require.Equal(t, FirestoreSettings{ProjectId: "test", DatabaseName: "staging", MaxDocuments: 5}, settings)
+	// The original settings are left untouched
+	require.JSONEq(t, `{"projectId": "test", "databaseName": "production", "maxDocuments": 5}`, string(pCtx.DataSourceInstanceSettings.JSONData))
+
+	_, err = withDatabaseName(pCtx, "(default)")
+	require.NoError(t, err)
+	for _, name := range []string{"abc", "Staging", "1db", "db-", "db_1", "db/x", "(other)"} {
+		_, err := withDatabaseName(pCtx, name)
+		require.Error(t, err, name)
+	}
+}
+
+func TestQueryInvalidDatabaseName(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"id"}}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from users", "databaseName": "bad name"}`)
+	require.Equal(t, backend.StatusBadRequest, resp.Status)
+	require.EqualError(t, resp.Error, `invalid database name "bad name"`)
+	require.Equal(t, 0, mock.calls())
+
+	resp = runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from users", "databaseName": "staging"}`)
+	require.NoError(t, resp.Error)
+}
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/pgollangi/fireql/pkg/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultQuotaRetryDelay = 1000 * time.Millisecond
+
+// quotaExceededError is returned when a query still exceeds the Firestore
+// quota after being retried.
+type quotaExceededError struct {
+	delay time.Duration
+	err   error
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded after retrying in %v: %v", e.delay, e.err)
+}
+
+func (e *quotaExceededError) Unwrap() error {
+	return e.err
+}
+
+// executeQuery executes the query with backoff on transient errors, then
+// retries it once more when Firestore still reports exhausted quota and
+// RetryOnQuotaExceeded is enabled.
+func executeQuery(ctx context.Context, executor queryExecutor, query string, settings FirestoreSettings) (*util.QueryResult, error) {
+	result, err := executeWithBackoff(ctx, executor, query, settings)
+	if err == nil || !settings.RetryOnQuotaExceeded || status.Code(err) != codes.ResourceExhausted {
+		return result, err
+	}
+
+	delay := defaultQuotaRetryDelay
+	if settings.QuotaRetryDelayMs > 0 {
+		delay = time.Duration(settings.QuotaRetryDelayMs) * time.Millisecond
+	}
+	log.DefaultLogger.Warn("Firestore quota exceeded, retrying query", "delay", delay)
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	result, err = executeWithBackoff(ctx, executor, query, settings)
+	if err != nil && status.Code(err) == codes.ResourceExhausted {
+		return nil, &quotaExceededError{delay: delay, err: err}
+	}
+	return result, err
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var quotaErr = status.Error(codes.ResourceExhausted, "quota exceeded")
+
+func TestQuotaRetrySucceeds(t *testing.T) {
+	mock := &mockExecutor{
+		results: []*util.QueryResult{nil, {Columns: []string{"id"}, Records: [][]interface{}{{1}, {2}}}},
+		errs:    []error{quotaErr, nil},
+	}
+	settings := FirestoreSettings{ProjectId: "test", RetryOnQuotaExceeded: true, QuotaRetryDelayMs: 1, RetryMaxAttempts: 1}
+
+	resp := runQuery(t, newMockDatasource(mock), settings, `{"query": "select id from users"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 2, mock.calls())
+	require.Equal(t, 2, resp.Frames[0].Rows())
+}
+
+func TestQuotaRetryFails(t *testing.T) {
+	mock := &mockExecutor{errs: []error{quotaErr}}
+	settings := FirestoreSettings{ProjectId: "test", RetryOnQuotaExceeded: true, QuotaRetryDelayMs: 1, RetryMaxAttempts: 1}
+
+	resp := runQuery(t, newMockDatasource(mock), settings, `{"query": "select id from users"}`)
+	require.Error(t, resp.Error)
+	require.Equal(t, backend.StatusTooManyRequests, resp.Status)
+	require.Contains(t, resp.Error.Error(), "1ms")
+	require.Equal(t, 2, mock.calls())
+}
+
+func TestQuotaRetryDisabled(t *testing.T) {
+	mock := &mockExecutor{errs: []error{quotaErr, nil}}
+
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test", RetryMaxAttempts: 1}, `{"query": "select id from users"}`)
+	require.Error(t, resp.Error)
+	require.Equal(t, 1, mock.calls())
+}
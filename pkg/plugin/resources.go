@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"golang.org/x/time/rate"
+)
+
+// CallResource serves the resource endpoints of the datasource.
+func (d *Datasource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	d.resourceHandlerOnce.Do(func() {
+		d.resourceHandler = httpadapter.New(d.newResourceMux())
+	})
+	return d.resourceHandler.CallResource(ctx, req, sender)
+}
+
+func (d *Datasource) newResourceMux() *http.ServeMux {
+	// Schema discovery endpoints may scan collections, so they share a
+	// per-instance rate limit.
+	discovery := rate.NewLimiter(rate.Every(time.Minute/discoveryRequestsPerMinute), discoveryRequestsPerMinute)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/clone", d.handleClone)
+	mux.HandleFunc("/cache", d.handleCachePurge)
+	mux.HandleFunc("/collections", rateLimited(discovery, d.handleCollections))
+	mux.HandleFunc("/collections/", rateLimited(discovery, d.handleCollectionDocuments))
+	mux.HandleFunc("/schema", rateLimited(discovery, d.handleSchema))
+	mux.HandleFunc("/capacity", rateLimited(discovery, d.handleCapacity))
+	return mux
+}
+
+// discoveryRequestsPerMinute is the rate limit of the collection, schema and
+// capacity endpoints of a datasource instance.
+const discoveryRequestsPerMinute = 5
+
+// rateLimited wraps handler to reject requests exceeding the rate of limiter.
+func rateLimited(limiter *rate.Limiter, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.Allow() {
+			writeError(w, http.StatusTooManyRequests, "too many requests, try again later")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// canWrite reports whether the user calling a resource may write to Firestore.
+func canWrite(r *http.Request) bool {
+	user := httpadapter.UserFromContext(r.Context())
+	return user != nil && (user.Role == "Admin" || user.Role == "Editor")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.DefaultLogger.Error("Writing resource response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type resourceResponseRecorder struct {
+	responses []*backend.CallResourceResponse
+}
+
+func (r *resourceResponseRecorder) Send(resp *backend.CallResourceResponse) error {
+	r.responses = append(r.responses, resp)
+	return nil
+}
+
+// callResource calls a resource endpoint as a user with the given role and
+// returns the response.
+func callResource(t *testing.T, ds *Datasource, settings FirestoreSettings, role, method, path, body string) *backend.CallResourceResponse {
+	jsonSettings, err := json.Marshal(settings)
+	require.NoError(t, err)
+
+	var recorder resourceResponseRecorder
+	err = ds.CallResource(context.Background(), &backend.CallResourceRequest{
+		PluginContext: backend.PluginContext{
+			User:                       &backend.User{Login: "test", Role: role},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Method: method,
+		Path:   strings.SplitN(path, "?", 2)[0],
+		URL:    path,
+		Body:   []byte(body),
+	}, &recorder)
+	require.NoError(t, err)
+	require.Len(t, recorder.responses, 1)
+	return recorder.responses[0]
+}
+
+func TestCallResourceNotFound(t *testing.T) {
+	resp := callResource(t, &Datasource{}, FirestoreSettings{ProjectId: "test"}, "Admin", "GET", "unknown", "")
+	require.Equal(t, 404, resp.Status)
+}
@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/pgollangi/fireql/pkg/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+)
+
+// isTransient reports whether err is a Firestore error worth retrying.
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+// executeWithBackoff executes the query, retrying transient errors up to
+// RetryMaxAttempts attempts in total (default 3). The backoff starts at
+// RetryInitialBackoffMs (default 100) and doubles each attempt, with up to
+// 50% jitter. No retry is attempted when its backoff would end past the
+// deadline of ctx.
+func executeWithBackoff(ctx context.Context, executor queryExecutor, query string, settings FirestoreSettings) (*util.QueryResult, error) {
+	attempts := defaultRetryMaxAttempts
+	if settings.RetryMaxAttempts > 0 {
+		attempts = settings.RetryMaxAttempts
+	}
+	backoff := defaultRetryInitialBackoff
+	if settings.RetryInitialBackoffMs > 0 {
+		backoff = time.Duration(settings.RetryInitialBackoffMs) * time.Millisecond
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := executeWithContext(ctx, executor, query)
+		if err == nil || attempt >= attempts || !isTransient(err) {
+			return result, err
+		}
+
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return nil, err
+		}
+		log.DefaultLogger.Warn("Transient Firestore error, retrying query", "attempt", attempt, "backoff", wait, "error", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var unavailableErr = status.Error(codes.Unavailable, "unavailable")
+
+func TestRetryTransientErrors(t *testing.T) {
+	mock := &mockExecutor{
+		results: []*util.QueryResult{nil, nil, {Columns: []string{"id"}, Records: [][]interface{}{{1}}}},
+		errs:    []error{unavailableErr, quotaErr, nil},
+	}
+	settings := FirestoreSettings{ProjectId: "test", RetryInitialBackoffMs: 1}
+
+	resp := runQuery(t, newMockDatasource(mock), settings, `{"query": "select id from users"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 3, mock.calls())
+	require.Equal(t, 1, resp.Frames[0].Rows())
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	mock := &mockExecutor{errs: []error{unavailableErr}}
+	settings := FirestoreSettings{RetryMaxAttempts: 4, RetryInitialBackoffMs: 1}
+
+	_, err := executeWithBackoff(context.Background(), mock, "select id from users", settings)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 4, mock.calls())
+}
+
+func TestRetryNonRetryableErrors(t *testing.T) {
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.NotFound, codes.PermissionDenied} {
+		mock := &mockExecutor{errs: []error{status.Error(code, code.String()), nil}}
+
+		_, err := executeWithBackoff(context.Background(), mock, "select id from users", FirestoreSettings{RetryInitialBackoffMs: 1})
+		require.Equal(t, code, status.Code(err))
+		require.Equal(t, 1, mock.calls(), code.String())
+	}
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	mock := &mockExecutor{errs: []error{unavailableErr, nil}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := executeWithBackoff(ctx, mock, "select id from users", FirestoreSettings{RetryInitialBackoffMs: 1000})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 1, mock.calls())
+	require.Less(t, time.Since(start), 50*time.Millisecond)
+}
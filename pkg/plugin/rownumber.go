@@ -0,0 +1,16 @@
+package plugin
+
+import "github.com/grafana/grafana-plugin-sdk-go/data"
+
+const rowNumberField = "__row_number"
+
+// addRowNumbers prepends the __row_number field to frame, numbering its rows
+// from 1 in their current order.
+func addRowNumbers(frame *data.Frame) {
+	numbers := make([]*int64, frame.Rows())
+	for i := range numbers {
+		n := int64(i + 1)
+		numbers[i] = &n
+	}
+	frame.Fields = append([]*data.Field{data.NewField(rowNumberField, nil, numbers)}, frame.Fields...)
+}
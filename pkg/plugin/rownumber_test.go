@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRowNumber(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"value"},
+		Records: [][]interface{}{{int64(30)}, {int64(20)}, {int64(10)}},
+	}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select value from metrics order by value desc limit 3", "addRowNumber": true,
+		  "transformers": [{"type": "sort", "options": {"field": "value"}}]}`)
+	require.NoError(t, resp.Error)
+
+	frame := resp.Frames[0]
+	require.Equal(t, rowNumberField, frame.Fields[0].Name)
+	value, _ := frame.FieldByName("value")
+	for i := 0; i < frame.Rows(); i++ {
+		require.Equal(t, int64(i+1), *frame.Fields[0].At(i).(*int64))
+	}
+	require.Equal(t, int64(10), *value.At(0).(*int64))
+}
+
+func TestAddRowNumberEmptyFrame(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{Columns: []string{"value"}}}}
+	resp := runQuery(t, newMockDatasource(mock), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select value from metrics", "addRowNumber": true}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, rowNumberField, resp.Frames[0].Fields[0].Name)
+	require.Equal(t, 0, resp.Frames[0].Rows())
+}
@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// sampleByTime down-samples the frame to at most points rows by splitting the
+// time range of timeField into equal-width bins and keeping the latest row of
+// each bin. When timeField is empty, the first field holding times is used.
+// Rows without a time are dropped.
+func sampleByTime(frame *data.Frame, timeField string, points int) (*data.Frame, error) {
+	if points <= 0 {
+		return nil, fmt.Errorf("TemporalSamplingPoints must be positive, got %d", points)
+	}
+	field, err := samplingTimeField(frame, timeField)
+	if err != nil {
+		return nil, err
+	}
+	if frame.Rows() <= points {
+		return frame, nil
+	}
+
+	times := make([]time.Time, field.Len())
+	valid := make([]bool, field.Len())
+	var min, max time.Time
+	for i := range times {
+		times[i], valid[i] = timeValue(field.At(i))
+		if !valid[i] {
+			continue
+		}
+		if min.IsZero() || times[i].Before(min) {
+			min = times[i]
+		}
+		if max.IsZero() || times[i].After(max) {
+			max = times[i]
+		}
+	}
+
+	width := max.Sub(min) / time.Duration(points)
+	latest := make([]int, points)
+	for i := range latest {
+		latest[i] = -1
+	}
+	for i, t := range times {
+		if !valid[i] {
+			continue
+		}
+		bin := points - 1
+		if width > 0 {
+			bin = int(t.Sub(min) / width)
+			if bin >= points {
+				bin = points - 1
+			}
+		}
+		if latest[bin] == -1 || !t.Before(times[latest[bin]]) {
+			latest[bin] = i
+		}
+	}
+
+	var rows []int
+	for _, row := range latest {
+		if row != -1 {
+			rows = append(rows, row)
+		}
+	}
+	return selectRows(frame, rows), nil
+}
+
+func samplingTimeField(frame *data.Frame, name string) (*data.Field, error) {
+	if name != "" {
+		field, _ := frame.FieldByName(name)
+		if field == nil {
+			return nil, fmt.Errorf("temporal sampling field %q not found", name)
+		}
+		return field, nil
+	}
+	for _, field := range frame.Fields {
+		if field.Type().Time() {
+			return field, nil
+		}
+		for i := 0; i < field.Len(); i++ {
+			if v, ok := field.ConcreteAt(i); ok {
+				if _, ok := timeValue(v); ok {
+					return field, nil
+				}
+				break
+			}
+		}
+	}
+	return nil, fmt.Errorf("no time field found for temporal sampling")
+}
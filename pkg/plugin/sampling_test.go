@@ -0,0 +1,34 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleByTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := make([]*string, 1000)
+	times := make([]*string, 1000)
+	for i := range times {
+		id := string(rune('a' + i%26))
+		ts := start.Add(time.Duration(i) * 100 * time.Millisecond).Format(time.RFC3339Nano)
+		ids[i], times[i] = &id, &ts
+	}
+	frame := data.NewFrame("response",
+		data.NewField(documentIDField, nil, ids),
+		data.NewField("created", nil, times),
+	)
+
+	sampled, err := sampleByTime(frame, "", 10)
+	require.NoError(t, err)
+	require.Equal(t, 10, sampled.Rows())
+	// Each 10 second bin keeps its last document
+	require.Equal(t, start.Add(9900*time.Millisecond).Format(time.RFC3339Nano), *sampled.Fields[1].At(0).(*string))
+	require.Equal(t, *times[999], *sampled.Fields[1].At(9).(*string))
+
+	_, err = sampleByTime(frame, "missing", 10)
+	require.Error(t, err)
+}
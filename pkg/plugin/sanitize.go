@@ -0,0 +1,28 @@
+package plugin
+
+import (
+	"html"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// sanitizeHTMLStrings HTML-escapes the values of the string fields of frame
+// so that user-generated content cannot inject markup into panels rendering
+// values as HTML.
+func sanitizeHTMLStrings(frame *data.Frame) {
+	for _, field := range frame.Fields {
+		switch field.Type() {
+		case data.FieldTypeString:
+			for i := 0; i < field.Len(); i++ {
+				field.Set(i, html.EscapeString(field.At(i).(string)))
+			}
+		case data.FieldTypeNullableString:
+			for i := 0; i < field.Len(); i++ {
+				if v, ok := field.At(i).(*string); ok && v != nil {
+					escaped := html.EscapeString(*v)
+					field.Set(i, &escaped)
+				}
+			}
+		}
+	}
+}
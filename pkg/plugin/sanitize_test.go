@@ -0,0 +1,25 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeHTMLStrings(t *testing.T) {
+	script := "<script>alert(1)</script>"
+	plain := "plain text"
+	frame := data.NewFrame("response",
+		data.NewField("comment", nil, []*string{&script, &plain, nil}),
+		data.NewField("title", nil, []string{script}),
+		data.NewField("count", nil, []*int64{nil}),
+	)
+
+	sanitizeHTMLStrings(frame)
+	require.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt;", *frame.Fields[0].At(0).(*string))
+	require.Equal(t, "plain text", *frame.Fields[0].At(1).(*string))
+	require.Nil(t, frame.Fields[0].At(2))
+	require.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt;", frame.Fields[1].At(0))
+	require.Equal(t, "<script>alert(1)</script>", script)
+}
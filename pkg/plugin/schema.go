@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// schemaSampleSize is the number of documents sampled by GET /schema.
+	schemaSampleSize = 20
+	// mixedFieldType is the type of fields holding values of several types.
+	mixedFieldType = "mixed"
+)
+
+type schemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type schemaResponse struct {
+	Fields []schemaField `json:"fields"`
+}
+
+// inferSchema returns the fields of docs with the type of their values,
+// sorted by name. Documents are processed concurrently, at most GOMAXPROCS
+// at a time. Fields holding values of different types are reported as
+// mixed, and nil values are ignored unless a field has no other value.
+func inferSchema(ctx context.Context, docs []map[string]interface{}) ([]schemaField, error) {
+	var types sync.Map
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for _, doc := range docs {
+		doc := doc
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for name, value := range doc {
+				mergeFieldType(&types, name, value)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	fields := []schemaField{}
+	types.Range(func(name, typ interface{}) bool {
+		fields = append(fields, schemaField{Name: name.(string), Type: typ.(string)})
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+func mergeFieldType(types *sync.Map, name string, value interface{}) {
+	typ := "null"
+	if value != nil {
+		typ = valueTypeName(value)
+	}
+	for {
+		current, loaded := types.LoadOrStore(name, typ)
+		if !loaded || current == typ || current == mixedFieldType || value == nil {
+			return
+		}
+		merged := mixedFieldType
+		if current == "null" {
+			merged = typ
+		}
+		if types.CompareAndSwap(name, current, merged) {
+			return
+		}
+	}
+}
+
+// handleSchema infers the fields of the collection given by the "collection"
+// query parameter from a sample of its documents, or returns those defined
+// by the ExternalSchemaURL endpoint when configured.
+func (d *Datasource) handleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	collection := r.URL.Query().Get("collection")
+	if collection == "" {
+		writeError(w, http.StatusBadRequest, "collection is required")
+		return
+	}
+
+	pCtx := httpadapter.PluginConfigFromContext(r.Context())
+	var settings FirestoreSettings
+	if err := json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if settings.ExternalSchemaURL != "" {
+		fields, err := d.externalSchema(r.Context(), settings.ExternalSchemaURL, collection)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, schemaResponse{Fields: fields})
+		return
+	}
+
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer release()
+	ref, err := collectionRef(client, collection)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	snapshots, err := ref.Limit(schemaSampleSize).Documents(r.Context()).GetAll()
+	logResourceAuditEvent(r, collection, len(snapshots), err)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	docs := make([]map[string]interface{}, len(snapshots))
+	for i, snapshot := range snapshots {
+		docs[i] = snapshot.Data()
+	}
+
+	fields, err := inferSchema(r.Context(), docs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, schemaResponse{Fields: fields})
+}
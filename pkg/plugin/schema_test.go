@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	docs := make([]map[string]interface{}, 50)
+	for i := range docs {
+		docs[i] = map[string]interface{}{
+			"id":                    int64(i),
+			fmt.Sprintf("f%02d", i): "value",
+		}
+		if i%2 == 0 {
+			docs[i]["score"] = 1.5
+		} else {
+			docs[i]["score"] = "n/a"
+		}
+		if i == 10 {
+			docs[i]["created"] = time.Now()
+			docs[i]["deleted"] = nil
+		}
+		if i == 20 {
+			docs[i]["created"] = nil
+		}
+	}
+
+	fields, err := inferSchema(context.Background(), docs)
+	require.NoError(t, err)
+	require.Len(t, fields, 50+4)
+
+	types := map[string]string{}
+	for _, field := range fields {
+		types[field.Name] = field.Type
+	}
+	for i := range docs {
+		require.Equal(t, "string", types[fmt.Sprintf("f%02d", i)])
+	}
+	require.Equal(t, "int64", types["id"])
+	require.Equal(t, mixedFieldType, types["score"])
+	require.Equal(t, "timestamp", types["created"])
+	require.Equal(t, "null", types["deleted"])
+}
+
+func TestCallResourceSchemaValidation(t *testing.T) {
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := callResource(t, &Datasource{}, settings, "Viewer", "GET", "schema", "")
+	require.Equal(t, 400, resp.Status)
+
+	resp = callResource(t, &Datasource{}, settings, "Viewer", "POST", "schema?collection=users", "")
+	require.Equal(t, 405, resp.Status)
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	resp = callResource(t, ds, settings, "Viewer", "GET", "schema?collection=users/abc", "")
+	require.Equal(t, 400, resp.Status, string(resp.Body))
+}
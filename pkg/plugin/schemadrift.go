@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// dynamicSchema records the type last observed for each column of each
+// collection. The zero value is ready to use.
+type dynamicSchema struct {
+	mu          sync.Mutex
+	collections map[string]map[string]string
+}
+
+type schemaChange struct {
+	Field   string
+	OldType string
+	NewType string
+}
+
+// update stores the observed column types of the collection and returns the
+// columns whose type differs from the one stored before, sorted by name.
+func (s *dynamicSchema) update(collection string, observed map[string]string) []schemaChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.collections == nil {
+		s.collections = map[string]map[string]string{}
+	}
+	stored, ok := s.collections[collection]
+	if !ok {
+		stored = map[string]string{}
+		s.collections[collection] = stored
+	}
+
+	var changes []schemaChange
+	for field, newType := range observed {
+		if oldType, ok := stored[field]; ok && oldType != newType {
+			changes = append(changes, schemaChange{Field: field, OldType: oldType, NewType: newType})
+		}
+		stored[field] = newType
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// reset forgets every stored type.
+func (s *dynamicSchema) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections = nil
+}
+
+// observedColumnTypes returns the type of the first non-nil value of every
+// column of the result. Columns without values are left out.
+func observedColumnTypes(result *util.QueryResult) map[string]string {
+	types := map[string]string{}
+	for i, column := range result.Columns {
+		for _, record := range result.Records {
+			if i < len(record) && record[i] != nil {
+				types[column] = valueTypeName(record[i])
+				break
+			}
+		}
+	}
+	return types
+}
+
+func valueTypeName(v interface{}) string {
+	switch v.(type) {
+	case time.Time:
+		return "timestamp"
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func schemaDriftNotice(changes []schemaChange) data.Notice {
+	descriptions := make([]string, len(changes))
+	for i, c := range changes {
+		descriptions[i] = fmt.Sprintf("%s (%s -> %s)", c.Field, c.OldType, c.NewType)
+	}
+	return data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "Field types changed since the last query: " + strings.Join(descriptions, ", "),
+	}
+}
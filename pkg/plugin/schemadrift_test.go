@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySchemaDrift(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{
+		{Columns: []string{"name", "score"}, Records: [][]interface{}{{"a", 1.5}}},
+		{Columns: []string{"name", "score"}, Records: [][]interface{}{{"a", "high"}}},
+		{Columns: []string{"name", "score"}, Records: [][]interface{}{{"a", "low"}}},
+	}}
+	ds := newMockDatasource(mock)
+	settings := FirestoreSettings{ProjectId: "test", AlertOnSchemaDrift: true}
+	query := `{"query": "select name, score from players"}`
+
+	resp := runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Empty(t, resp.Frames[0].Meta.Notices)
+
+	resp = runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Equal(t, []data.Notice{{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "Field types changed since the last query: score (float64 -> string)",
+	}}, resp.Frames[0].Meta.Notices)
+
+	// The stored schema was updated after alerting
+	resp = runQuery(t, ds, settings, query)
+	require.NoError(t, resp.Error)
+	require.Empty(t, resp.Frames[0].Meta.Notices)
+}
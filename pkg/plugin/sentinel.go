@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"strconv"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// replaceSentinelValues replaces with nil the values of result matching one
+// of the sentinels of their column. Strings must match a sentinel exactly;
+// numbers match sentinels parsing to the same number, so "-1" matches both
+// int64(-1) and -1.0.
+func replaceSentinelValues(result *util.QueryResult, sentinels map[string][]string) {
+	for column, name := range result.Columns {
+		values, ok := sentinels[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		var numbers []float64
+		for _, value := range values {
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				numbers = append(numbers, f)
+			}
+		}
+
+		for _, record := range result.Records {
+			if column >= len(record) || record[column] == nil {
+				continue
+			}
+			if isSentinel(record[column], values, numbers) {
+				record[column] = nil
+			}
+		}
+	}
+}
+
+func isSentinel(value interface{}, values []string, numbers []float64) bool {
+	if s, ok := value.(string); ok {
+		return containsString(values, s)
+	}
+	f, ok := toFloat64(value)
+	if !ok {
+		return false
+	}
+	for _, number := range numbers {
+		if f == number {
+			return true
+		}
+	}
+	return false
+}
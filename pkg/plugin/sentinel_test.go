@@ -0,0 +1,34 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceSentinelValues(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"temperature", "status", "count"},
+		Records: [][]interface{}{
+			{21.5, "ok", int64(-1)},
+			{-1.0, "N/A", int64(3)},
+			{-1.5, "null", int64(-1)},
+		},
+	}
+
+	replaceSentinelValues(result, map[string][]string{
+		"temperature": {"-1"},
+		"status":      {"N/A", "null"},
+	})
+	require.Equal(t, [][]interface{}{
+		{21.5, "ok", int64(-1)},
+		{nil, nil, int64(3)},
+		{-1.5, nil, int64(-1)},
+	}, result.Records)
+
+	replaceSentinelValues(result, map[string][]string{"count": {"-1.0"}})
+	require.Nil(t, result.Records[0][2])
+	require.Equal(t, int64(3), result.Records[1][2])
+	require.Nil(t, result.Records[2][2])
+}
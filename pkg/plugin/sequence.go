@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	sequenceGapField           = "__sequence_gap"
+	defaultExpectedSequenceGap = 1
+)
+
+// addSequenceGaps sorts the frame by the integer values of field and appends
+// a __sequence_gap field holding, for each row, the difference with the
+// previous value minus expectedGap (default 1). Non-zero gaps reveal missing
+// or duplicate sequence numbers. Rows without an integer value are moved to
+// the end and, like the first row, get a nil gap.
+func addSequenceGaps(frame *data.Frame, field string, expectedGap int) (*data.Frame, error) {
+	if expectedGap == 0 {
+		expectedGap = defaultExpectedSequenceGap
+	}
+	sequence, _ := frame.FieldByName(field)
+	if sequence == nil {
+		return nil, fmt.Errorf("sequence field %q not found", field)
+	}
+
+	values := make([]int64, sequence.Len())
+	valid := make([]bool, sequence.Len())
+	rows := make([]int, sequence.Len())
+	for i := range rows {
+		rows[i] = i
+		if v, ok := sequence.ConcreteAt(i); ok {
+			values[i], valid[i] = sequenceValue(v)
+		}
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		ra, rb := rows[a], rows[b]
+		if valid[ra] != valid[rb] {
+			return valid[ra]
+		}
+		return valid[ra] && values[ra] < values[rb]
+	})
+
+	sorted := selectRows(frame, rows)
+	gaps := make([]*int64, len(rows))
+	for i := 1; i < len(rows); i++ {
+		previous, current := rows[i-1], rows[i]
+		if !valid[previous] || !valid[current] {
+			continue
+		}
+		gap := values[current] - values[previous] - int64(expectedGap)
+		gaps[i] = &gap
+	}
+	sorted.Fields = append(sorted.Fields, data.NewField(sequenceGapField, nil, gaps))
+	return sorted, nil
+}
+
+// sequenceValue converts a frame value holding an integral number to int64.
+func sequenceValue(v interface{}) (int64, bool) {
+	if s, ok := v.(string); ok {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		v = f
+	}
+	f, ok := toFloat64(v)
+	if !ok || f != math.Trunc(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return int64(f), true
+}
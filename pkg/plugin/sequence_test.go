@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSequenceGaps(t *testing.T) {
+	ids := []string{"5", "1", "n/a", "4", "2"}
+	frame := data.NewFrame("response",
+		data.NewField("seq", nil, []*string{&ids[0], &ids[1], &ids[2], &ids[3], &ids[4]}),
+	)
+
+	sorted, err := addSequenceGaps(frame, "seq", 0)
+	require.NoError(t, err)
+	seq, _ := sorted.FieldByName("seq")
+	gaps, _ := sorted.FieldByName(sequenceGapField)
+	var order []string
+	for i := 0; i < sorted.Rows(); i++ {
+		order = append(order, *seq.At(i).(*string))
+	}
+	require.Equal(t, []string{"1", "2", "4", "5", "n/a"}, order)
+
+	require.Nil(t, gaps.At(0))
+	require.Equal(t, int64(0), *gaps.At(1).(*int64))
+	require.Equal(t, int64(1), *gaps.At(2).(*int64))
+	require.Equal(t, int64(0), *gaps.At(3).(*int64))
+	require.Nil(t, gaps.At(4))
+}
+
+func TestAddSequenceGapsExpectedGap(t *testing.T) {
+	frame := data.NewFrame("response", data.NewField("seq", nil, []int64{10, 20, 40}))
+
+	sorted, err := addSequenceGaps(frame, "seq", 10)
+	require.NoError(t, err)
+	gaps := sorted.Fields[1]
+	require.Equal(t, int64(0), *gaps.At(1).(*int64))
+	require.Equal(t, int64(10), *gaps.At(2).(*int64))
+
+	_, err = addSequenceGaps(frame, "missing", 0)
+	require.Error(t, err)
+}
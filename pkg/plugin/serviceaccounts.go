@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"math/rand"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// WeightedServiceAccount is a service account used for a share of the
+// queries proportional to its weight.
+type WeightedServiceAccount struct {
+	JSON   string
+	Weight int
+}
+
+// serviceAccountIntn picks a random number in [0, n). It is safe for
+// concurrent use and replaced in tests.
+var serviceAccountIntn = rand.Intn
+
+// selectServiceAccount returns the service account JSON to authenticate with.
+// When weighted service accounts are configured, one is picked at random
+// according to the weights; entries without a positive weight are never
+// picked. Otherwise the secure serviceAccount setting is used.
+func selectServiceAccount(settings FirestoreSettings, pCtx backend.PluginContext) string {
+	totalWeight := 0
+	for _, account := range settings.ServiceAccounts {
+		if account.Weight > 0 {
+			totalWeight += account.Weight
+		}
+	}
+	if totalWeight == 0 {
+		return pCtx.DataSourceInstanceSettings.DecryptedSecureJSONData["serviceAccount"]
+	}
+
+	n := serviceAccountIntn(totalWeight)
+	for _, account := range settings.ServiceAccounts {
+		if account.Weight <= 0 {
+			continue
+		}
+		if n < account.Weight {
+			return account.JSON
+		}
+		n -= account.Weight
+	}
+	return ""
+}
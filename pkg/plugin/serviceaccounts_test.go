@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectServiceAccountWeights(t *testing.T) {
+	// Walk through every outcome of the random draw once per round
+	next := 0
+	serviceAccountIntn = func(n int) int {
+		defer func() { next++ }()
+		return next % n
+	}
+	defer func() { serviceAccountIntn = rand.Intn }()
+
+	settings := FirestoreSettings{ServiceAccounts: []WeightedServiceAccount{
+		{JSON: "a", Weight: 5},
+		{JSON: "disabled", Weight: 0},
+		{JSON: "b", Weight: 3},
+		{JSON: "c", Weight: 2},
+	}}
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}}
+
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		counts[selectServiceAccount(settings, pCtx)]++
+	}
+	require.Equal(t, map[string]int{"a": 50, "b": 30, "c": 20}, counts)
+}
+
+func TestSelectServiceAccountDistribution(t *testing.T) {
+	settings := FirestoreSettings{ServiceAccounts: []WeightedServiceAccount{
+		{JSON: "a", Weight: 3},
+		{JSON: "b", Weight: 1},
+	}}
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{}}
+
+	counts := map[string]int{}
+	for i := 0; i < 100; i++ {
+		counts[selectServiceAccount(settings, pCtx)]++
+	}
+	require.Equal(t, 100, counts["a"]+counts["b"])
+	require.InDelta(t, 75, counts["a"], 20)
+}
+
+func TestSelectServiceAccountFallback(t *testing.T) {
+	pCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+		DecryptedSecureJSONData: map[string]string{"serviceAccount": "secure"},
+	}}
+	require.Equal(t, "secure", selectServiceAccount(FirestoreSettings{}, pCtx))
+}
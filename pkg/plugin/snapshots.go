@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// changeTypeField is the plugin-injected field holding the kind of change of
+// each document of a snapshot stream frame.
+const changeTypeField = "__change_type"
+
+// StreamQuery configures a live stream of the documents of Collection
+// matching the optional WhereClause, a FireQL WHERE condition. Frames are
+// pushed whenever documents are added, modified or removed. They hold every
+// matching document unless IncludeDocumentChanges is set, in which case they
+// hold the changed documents with a __change_type field.
+type StreamQuery struct {
+	Collection             string
+	WhereClause            string
+	IncludeDocumentChanges bool
+}
+
+// query returns the FireQL query selecting the documents of the stream.
+func (s StreamQuery) query() string {
+	query := "SELECT * FROM " + s.Collection
+	if s.WhereClause != "" {
+		query += " WHERE " + s.WhereClause
+	}
+	return query
+}
+
+// changeTypeName returns the __change_type value of a document change kind.
+func changeTypeName(kind firestore.DocumentChangeKind) string {
+	switch kind {
+	case firestore.DocumentAdded:
+		return "ADDED"
+	case firestore.DocumentModified:
+		return "MODIFIED"
+	case firestore.DocumentRemoved:
+		return "REMOVED"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", kind)
+}
+
+// runSnapshotStream listens to the snapshots of the stream query and sends a
+// frame to the channel for each of them, until ctx is done.
+func (d *Datasource) runSnapshotStream(ctx context.Context, pCtx backend.PluginContext, sq StreamQuery, sender *backend.StreamSender) error {
+	var settings FirestoreSettings
+	if err := json.Unmarshal(pCtx.DataSourceInstanceSettings.JSONData, &settings); err != nil {
+		return err
+	}
+	stmt, err := parseSelectStatement(sq.query())
+	if err != nil {
+		return err
+	}
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	q, err := stmt.firestoreQuery(client)
+	if err != nil {
+		return err
+	}
+	snapshots := q.Snapshots(ctx)
+	defer snapshots.Stop()
+	// The stream is audited once, when its first snapshot or error arrives
+	audited := false
+	for {
+		snapshot, err := snapshots.Next()
+		if ctx.Err() != nil || status.Code(err) == codes.Canceled {
+			return nil
+		}
+		if !audited {
+			audited = true
+			rows := 0
+			if snapshot != nil {
+				rows = snapshot.Size
+			}
+			logAuditEvent(ctx, pCtx, sq.query(), sq.Collection, rows, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		var docs []*firestore.DocumentSnapshot
+		var changeTypes []*string
+		if sq.IncludeDocumentChanges {
+			for _, change := range snapshot.Changes {
+				changeType := changeTypeName(change.Kind)
+				docs = append(docs, change.Doc)
+				changeTypes = append(changeTypes, &changeType)
+			}
+		} else if docs, err = snapshot.Documents.GetAll(); err != nil {
+			return err
+		}
+
+		frame := newResponseFrame(stmt.result(docs), settings.LegacyStringMode)
+		for i, doc := range docs {
+			id := doc.Ref.ID
+			frame.Fields[0].Set(i, &id)
+		}
+		if sq.IncludeDocumentChanges {
+			frame.Fields = append(frame.Fields, data.NewField(changeTypeField, nil, changeTypes))
+		}
+		if err := sender.SendFrame(frame, data.IncludeAll); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamQuery(t *testing.T) {
+	require.Equal(t, "SELECT * FROM orders", StreamQuery{Collection: "orders"}.query())
+	require.Equal(t, "SELECT * FROM orders WHERE status = 'open'", StreamQuery{Collection: "orders", WhereClause: "status = 'open'"}.query())
+
+	require.Equal(t, "ADDED", changeTypeName(firestore.DocumentAdded))
+	require.Equal(t, "MODIFIED", changeTypeName(firestore.DocumentModified))
+	require.Equal(t, "REMOVED", changeTypeName(firestore.DocumentRemoved))
+
+	sq := &StreamQuery{Collection: "orders", WhereClause: "status = 'open'"}
+	resp, err := subscribeStream(t, FirestoreSettings{ProjectId: "test"}, liveChannelPath(sq.query()), &FirestoreQuery{StreamQuery: sq})
+	require.NoError(t, err)
+	require.Equal(t, backend.SubscribeStreamStatusOK, resp.Status)
+}
+
+// framePacketSender decodes the frames sent to a stream.
+type framePacketSender chan *data.Frame
+
+func (s framePacketSender) Send(packet *backend.StreamPacket) error {
+	var frame data.Frame
+	if err := json.Unmarshal(packet.Data, &frame); err != nil {
+		return err
+	}
+	s <- &frame
+	return nil
+}
+
+func TestEmulatorSnapshotStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	_, err := client.Collection("stream_orders").Doc("o1").Set(ctx, map[string]interface{}{"status": "open"})
+	require.NoError(t, err)
+
+	queryJSON, err := json.Marshal(FirestoreQuery{StreamQuery: &StreamQuery{Collection: "stream_orders", IncludeDocumentChanges: true}})
+	require.NoError(t, err)
+	frames := make(framePacketSender, 10)
+	ds := &Datasource{}
+	defer ds.Dispose()
+	done := make(chan error, 1)
+	go func() {
+		done <- ds.RunStream(ctx, &backend.RunStreamRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(`{"ProjectId": "test"}`)},
+			},
+			Data: queryJSON,
+		}, backend.NewStreamSender(frames))
+	}()
+
+	changeType := func(frame *data.Frame) string {
+		field, _ := frame.FieldByName(changeTypeField)
+		require.NotNil(t, field)
+		return *field.At(0).(*string)
+	}
+	next := func() *data.Frame {
+		select {
+		case frame := <-frames:
+			return frame
+		case <-time.After(10 * time.Second):
+			t.Fatal("no frame received")
+			return nil
+		}
+	}
+
+	require.Equal(t, "ADDED", changeType(next()))
+	_, err = client.Collection("stream_orders").Doc("o1").Set(ctx, map[string]interface{}{"status": "closed"})
+	require.NoError(t, err)
+	require.Equal(t, "MODIFIED", changeType(next()))
+	_, err = client.Collection("stream_orders").Doc("o1").Delete(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "REMOVED", changeType(next()))
+
+	cancel()
+	require.NoError(t, <-done)
+}
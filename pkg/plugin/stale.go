@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// addStaleDataNotice adds a warning to frame when the latest time of the
+// timeField column is more than thresholdMinutes before now. Frames without
+// any parsable time are left untouched.
+func addStaleDataNotice(frame *data.Frame, timeField string, thresholdMinutes int, now time.Time) error {
+	if thresholdMinutes <= 0 {
+		return fmt.Errorf("StaleDataWarningMinutes must be positive, got %d", thresholdMinutes)
+	}
+	field, _ := frame.FieldByName(timeField)
+	if field == nil {
+		return fmt.Errorf("stale data time field %q not found", timeField)
+	}
+
+	var latest time.Time
+	for i := 0; i < field.Len(); i++ {
+		if t, ok := timeValue(field.At(i)); ok && t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return nil
+	}
+
+	if age := now.Sub(latest); age > time.Duration(thresholdMinutes)*time.Minute {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Most recent data is %d minutes old", int(age.Minutes())),
+		})
+	}
+	return nil
+}
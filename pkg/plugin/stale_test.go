@@ -0,0 +1,32 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddStaleDataNotice(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	older := now.Add(-time.Hour)
+	latest := now.Add(-10 * time.Minute)
+	frame := data.NewFrame("response", data.NewField("updated", nil, []*time.Time{&older, &latest, nil}))
+
+	require.NoError(t, addStaleDataNotice(frame, "updated", 5, now))
+	require.Equal(t, []data.Notice{{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "Most recent data is 10 minutes old",
+	}}, frame.Meta.Notices)
+
+	fresh := data.NewFrame("response", data.NewField("updated", nil, []*time.Time{&latest}))
+	require.NoError(t, addStaleDataNotice(fresh, "updated", 15, now))
+	require.Nil(t, fresh.Meta)
+}
+
+func TestAddStaleDataNoticeErrors(t *testing.T) {
+	frame := data.NewFrame("response", data.NewField("updated", nil, []*time.Time{}))
+	require.Error(t, addStaleDataNotice(frame, "missing", 5, time.Now()))
+	require.Error(t, addStaleDataNotice(frame, "updated", 0, time.Now()))
+}
@@ -0,0 +1,300 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/xwb1989/sqlparser"
+)
+
+// selectStatement is the subset of a FireQL SELECT statement that can be
+// translated into a native Firestore query. It backs the features that need
+// direct access to the Firestore SDK rather than going through fireql.
+type selectStatement struct {
+	// Star is set when the select list contains "*".
+	Star            bool
+	Columns         []statementColumn
+	Collection      string
+	CollectionGroup bool
+	Filters         []statementFilter
+	OrderBy         []statementOrder
+	Limit           int
+}
+
+type statementColumn struct {
+	Field string
+	Alias string
+}
+
+type statementFilter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+type statementOrder struct {
+	Field     string
+	Direction firestore.Direction
+}
+
+// parseSelectStatement parses a FireQL SELECT statement.
+func parseSelectStatement(query string) (*selectStatement, error) {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil, errors.New("only SELECT statements are supported")
+	}
+	if len(sel.From) != 1 {
+		return nil, errors.New("there must be a FROM collection")
+	}
+
+	var parsed selectStatement
+	for _, expr := range sel.SelectExprs {
+		switch expr := expr.(type) {
+		case *sqlparser.StarExpr:
+			parsed.Star = true
+		case *sqlparser.AliasedExpr:
+			col, ok := expr.Expr.(*sqlparser.ColName)
+			if !ok {
+				return nil, fmt.Errorf("unsupported select expression: %s", sqlparser.String(expr))
+			}
+			column := statementColumn{Field: columnPath(col), Alias: expr.As.String()}
+			if column.Alias == "" {
+				column.Alias = column.Field
+			}
+			parsed.Columns = append(parsed.Columns, column)
+		default:
+			return nil, fmt.Errorf("unsupported select expression: %s", sqlparser.String(expr))
+		}
+	}
+
+	parsed.Collection = strings.Trim(sqlparser.String(sel.From[0]), "`")
+	if strings.HasPrefix(parsed.Collection, "[") && strings.HasSuffix(parsed.Collection, "]") {
+		parsed.Collection = strings.TrimSuffix(strings.TrimPrefix(parsed.Collection, "["), "]")
+		parsed.CollectionGroup = true
+	}
+
+	if sel.Where != nil {
+		if err := parsed.addWhere(sel.Where.Expr); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, order := range sel.OrderBy {
+		col, ok := order.Expr.(*sqlparser.ColName)
+		if !ok {
+			return nil, fmt.Errorf("unsupported ORDER BY: %s", sqlparser.String(order.Expr))
+		}
+		direction := firestore.Asc
+		if order.Direction == sqlparser.DescScr {
+			direction = firestore.Desc
+		}
+		parsed.OrderBy = append(parsed.OrderBy, statementOrder{Field: columnPath(col), Direction: direction})
+	}
+
+	if sel.Limit != nil {
+		limit, err := statementValue(sel.Limit.Rowcount)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := limit.(int)
+		if !ok {
+			return nil, fmt.Errorf("invalid LIMIT: %s", sqlparser.String(sel.Limit.Rowcount))
+		}
+		parsed.Limit = n
+	}
+
+	return &parsed, nil
+}
+
+func (s *selectStatement) addWhere(expr sqlparser.Expr) error {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		if err := s.addWhere(expr.Left); err != nil {
+			return err
+		}
+		return s.addWhere(expr.Right)
+	case *sqlparser.ParenExpr:
+		return s.addWhere(expr.Expr)
+	case *sqlparser.FuncExpr:
+		filter, err := arrayFilter(expr)
+		if err != nil {
+			return err
+		}
+		s.Filters = append(s.Filters, filter)
+		return nil
+	case *sqlparser.ComparisonExpr:
+		col, ok := expr.Left.(*sqlparser.ColName)
+		if !ok {
+			return fmt.Errorf("unsupported WHERE clause: %s", sqlparser.String(expr))
+		}
+		op, ok := firestoreOperators[expr.Operator]
+		if !ok {
+			return fmt.Errorf("unsupported operator %q", expr.Operator)
+		}
+		value, err := statementValue(expr.Right)
+		if err != nil {
+			return err
+		}
+		s.Filters = append(s.Filters, statementFilter{Field: columnPath(col), Op: op, Value: value})
+		return nil
+	}
+	return fmt.Errorf("unsupported WHERE clause: %s", sqlparser.String(expr))
+}
+
+// firestoreOperators maps SQL comparison operators to Firestore operators.
+var firestoreOperators = map[string]string{
+	sqlparser.EqualStr:        "==",
+	sqlparser.NotEqualStr:     "!=",
+	sqlparser.LessThanStr:     "<",
+	sqlparser.LessEqualStr:    "<=",
+	sqlparser.GreaterThanStr:  ">",
+	sqlparser.GreaterEqualStr: ">=",
+	sqlparser.InStr:           "in",
+	sqlparser.NotInStr:        "not-in",
+}
+
+// columnPath returns the Firestore field path of a column, joining
+// unquoted qualified names such as address.city.
+func columnPath(col *sqlparser.ColName) string {
+	if col.Qualifier.IsEmpty() {
+		return col.Name.String()
+	}
+	return sqlparser.String(col.Qualifier) + "." + col.Name.String()
+}
+
+func statementValue(expr sqlparser.Expr) (interface{}, error) {
+	switch expr := expr.(type) {
+	case sqlparser.BoolVal:
+		return bool(expr), nil
+	case *sqlparser.NullVal:
+		return nil, nil
+	case *sqlparser.SQLVal:
+		switch expr.Type {
+		case sqlparser.IntVal:
+			return strconv.Atoi(string(expr.Val))
+		case sqlparser.FloatVal:
+			return strconv.ParseFloat(string(expr.Val), 64)
+		default:
+			return string(expr.Val), nil
+		}
+	case sqlparser.ValTuple:
+		values := make([]interface{}, len(expr))
+		for i, e := range expr {
+			v, err := statementValue(e)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	case *sqlparser.ParenExpr:
+		return statementValue(expr.Expr)
+	}
+	return nil, fmt.Errorf("unsupported value: %s", sqlparser.String(expr))
+}
+
+// firestoreQuery builds the native Firestore query for the statement. It
+// fails when the FROM clause names a document rather than a collection.
+func (s *selectStatement) firestoreQuery(client *firestore.Client) (firestore.Query, error) {
+	var q firestore.Query
+	if s.CollectionGroup {
+		q = client.CollectionGroup(s.Collection).Query
+	} else {
+		ref, err := collectionRef(client, s.Collection)
+		if err != nil {
+			return firestore.Query{}, err
+		}
+		q = ref.Query
+	}
+	for _, f := range s.Filters {
+		q = q.Where(f.Field, f.Op, f.Value)
+	}
+	for _, o := range s.OrderBy {
+		q = q.OrderBy(o.Field, o.Direction)
+	}
+	if s.Limit > 0 {
+		q = q.Limit(s.Limit)
+	}
+	return q, nil
+}
+
+// result converts document snapshots into the same shape fireql returns.
+// A "*" selection expands to the union of all document fields, in sorted
+// order per document. Missing fields are returned as nil. Collection group
+// results also hold the __collection_path of each document.
+func (s *selectStatement) result(docs []*firestore.DocumentSnapshot) *util.QueryResult {
+	columns := append([]statementColumn(nil), s.Columns...)
+	if s.Star {
+		seen := map[string]bool{}
+		for _, c := range columns {
+			seen[c.Alias] = true
+		}
+		for _, doc := range docs {
+			var keys []string
+			for key := range doc.Data() {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				columns = append(columns, statementColumn{Field: key, Alias: key})
+			}
+		}
+	}
+
+	result := &util.QueryResult{Records: make([][]interface{}, len(docs))}
+	for _, c := range columns {
+		result.Columns = append(result.Columns, c.Alias)
+	}
+	if s.CollectionGroup {
+		result.Columns = append(result.Columns, collectionPathField)
+	}
+	for i, doc := range docs {
+		record := make([]interface{}, len(columns))
+		for j, c := range columns {
+			if c.Field == firestore.DocumentID {
+				record[j] = doc.Ref.ID
+				continue
+			}
+			if v, err := doc.DataAt(c.Field); err == nil {
+				record[j] = v
+			}
+		}
+		if s.CollectionGroup {
+			record = append(record, documentsPath(doc.Ref.Parent.Path))
+		}
+		result.Records[i] = record
+	}
+	return result
+}
+
+// runStatement runs the statement directly against Firestore.
+func (d *Datasource) runStatement(ctx context.Context, pCtx backend.PluginContext, stmt *selectStatement) (*util.QueryResult, error) {
+	client, release, err := d.firestoreClient(pCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	q, err := stmt.firestoreQuery(client)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := q.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return stmt.result(docs), nil
+}
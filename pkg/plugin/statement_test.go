@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectStatement(t *testing.T) {
+	stmt, err := parseSelectStatement("select * from users where `address.city` = 'Glendale' and id >= 3 and status in ('a', 'b') order by id desc limit 5")
+	require.NoError(t, err)
+	require.True(t, stmt.Star)
+	require.Empty(t, stmt.Columns)
+	require.Equal(t, "users", stmt.Collection)
+	require.False(t, stmt.CollectionGroup)
+	require.Equal(t, []statementFilter{
+		{Field: "address.city", Op: "==", Value: "Glendale"},
+		{Field: "id", Op: ">=", Value: 3},
+		{Field: "status", Op: "in", Value: []interface{}{"a", "b"}},
+	}, stmt.Filters)
+	require.Equal(t, []statementOrder{{Field: "id", Direction: firestore.Desc}}, stmt.OrderBy)
+	require.Equal(t, 5, stmt.Limit)
+}
+
+func TestParseSelectStatementCollectionGroup(t *testing.T) {
+	stmt, err := parseSelectStatement("select * from `[orders]`")
+	require.NoError(t, err)
+	require.Equal(t, "orders", stmt.Collection)
+	require.True(t, stmt.CollectionGroup)
+}
+
+func TestParseSelectStatementColumns(t *testing.T) {
+	stmt, err := parseSelectStatement("select __name__, `address.city` as city, age from users")
+	require.NoError(t, err)
+	require.False(t, stmt.Star)
+	require.Equal(t, []statementColumn{
+		{Field: "__name__", Alias: "__name__"},
+		{Field: "address.city", Alias: "city"},
+		{Field: "age", Alias: "age"},
+	}, stmt.Columns)
+}
+
+func TestParseSelectStatementErrors(t *testing.T) {
+	for _, query := range []string{
+		"delete from users",
+		"select * from users where id like 'a%'",
+		"select * from users where id = 1 or id = 2",
+		"select count(*) from users",
+	} {
+		_, err := parseSelectStatement(query)
+		require.Error(t, err, query)
+	}
+}
+
+func TestStatementDocumentPath(t *testing.T) {
+	client := newFirestoreTestClient(context.Background())
+	defer client.Close()
+
+	stmt, err := parseSelectStatement("select * from `users/abc`")
+	require.NoError(t, err)
+	_, err = stmt.firestoreQuery(client)
+	require.Error(t, err)
+
+	executor := &clientExecutor{client: func() (*firestore.Client, func(), error) { return client, func() {}, nil }}
+	_, err = executor.ExecuteContext(context.Background(), "select * from `users/abc`")
+	require.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	fromPathPattern    = regexp.MustCompile("(?i)(\\bFROM\\s+)`?([\\w${}-]+(?:/[\\w${}-]+)+)`?")
+	templateVarPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+)
+
+// quoteSubcollectionPath quotes the sub-collection path of the FROM clause
+// of query, e.g. FROM users/${userId}/orders, so that it parses as a single
+// collection. The ${name} template variables of the path are replaced with
+// their value in vars first.
+func quoteSubcollectionPath(query string, vars map[string]string) (string, error) {
+	match := fromPathPattern.FindStringSubmatchIndex(query)
+	if match == nil {
+		return query, nil
+	}
+
+	var err error
+	path := templateVarPattern.ReplaceAllStringFunc(query[match[4]:match[5]], func(v string) string {
+		name := v[2 : len(v)-1]
+		value, ok := vars[name]
+		if !ok {
+			err = fmt.Errorf("unknown template variable %q in collection path", name)
+		} else if value == "" || strings.ContainsAny(value, "/`") {
+			err = fmt.Errorf("invalid value %q of template variable %q in collection path", value, name)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.Count(path, "/")%2 != 0 {
+		return "", fmt.Errorf("%q is not a collection path", path)
+	}
+	return query[:match[3]] + "`" + path + "`" + query[match[1]:], nil
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteSubcollectionPath(t *testing.T) {
+	vars := map[string]string{"userId": "u1", "orderId": "o1"}
+	tests := []struct {
+		query, expected string
+	}{
+		{"select * from users", "select * from users"},
+		{"select * from users/u1/orders where total > 1", "select * from `users/u1/orders` where total > 1"},
+		{"SELECT * FROM `users/u1/orders`", "SELECT * FROM `users/u1/orders`"},
+		{"select * from users/${userId}/orders/${orderId}/items", "select * from `users/u1/orders/o1/items`"},
+	}
+	for _, test := range tests {
+		query, err := quoteSubcollectionPath(test.query, vars)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, query)
+	}
+
+	for _, query := range []string{
+		"select * from users/${unknown}/orders",
+		"select * from users/u1",
+		"select * from users/${userId}/orders",
+	} {
+		_, err := quoteSubcollectionPath(query, map[string]string{"userId": "a/b"})
+		require.Error(t, err, query)
+	}
+}
+
+func TestQueryDataSubcollections(t *testing.T) {
+	ctx := context.Background()
+	client := newFirestoreTestClient(ctx)
+	defer client.Close()
+	order := client.Collection("sub_users").Doc("u1").Collection("orders").Doc("o1")
+	_, err := order.Set(ctx, map[string]interface{}{"total": 10})
+	require.NoError(t, err)
+	_, err = order.Collection("items").Doc("i1").Set(ctx, map[string]interface{}{"sku": "abc"})
+	require.NoError(t, err)
+
+	ds := &Datasource{}
+	defer ds.Dispose()
+	settings := FirestoreSettings{ProjectId: "test"}
+
+	resp := runQuery(t, ds, settings, `{"query": "select total from sub_users/${userId}/orders", "templateVars": {"userId": "u1"}}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, 1, resp.Frames[0].Rows())
+
+	resp = runQuery(t, ds, settings, `{"query": "select sku from sub_users/u1/orders/o1/items"}`)
+	require.NoError(t, resp.Error)
+	sku, _ := resp.Frames[0].FieldByName("sku")
+	require.Equal(t, "abc", *sku.At(0).(*string))
+}
@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pgollangi/fireql/pkg/util"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentSubQueries caps the number of sub-queries run at the same time.
+const maxConcurrentSubQueries = 4
+
+// subQueryError is the error of one query of a union.
+type subQueryError struct {
+	query string
+	err   error
+}
+
+func (e *subQueryError) Error() string {
+	return fmt.Sprintf("sub-query %q: %v", e.query, e.err)
+}
+
+func (e *subQueryError) Unwrap() error {
+	return e.err
+}
+
+// runUnion runs the queries concurrently and appends their results in query
+// order. When abortOnFirstError is set, the first failure cancels the queries
+// not started yet and is returned without any results. Otherwise the results
+// of the successful queries are returned along with the errors of the others.
+func runUnion(ctx context.Context, queries []string, run func(ctx context.Context, query string) (*util.QueryResult, error), abortOnFirstError bool) (*util.QueryResult, []error, error) {
+	results := make([]*util.QueryResult, len(queries))
+
+	if abortOnFirstError {
+		g, ctx := errgroup.WithContext(ctx)
+		g.SetLimit(maxConcurrentSubQueries)
+		for i, query := range queries {
+			i, query := i, query
+			g.Go(func() error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				result, err := run(ctx, query)
+				if err != nil {
+					return &subQueryError{query: query, err: err}
+				}
+				results[i] = result
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, nil, err
+		}
+		return mergeResults(results), nil, nil
+	}
+
+	errs := make([]error, len(queries))
+	sem := make(chan struct{}, maxConcurrentSubQueries)
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, query string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result, err := run(ctx, query)
+			if err != nil {
+				errs[i] = &subQueryError{query: query, err: err}
+				return
+			}
+			results[i] = result
+		}(i, query)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == len(queries) {
+		return nil, nil, failed[0]
+	}
+	return mergeResults(results), failed, nil
+}
+
+func mergeResults(results []*util.QueryResult) *util.QueryResult {
+	merged := &util.QueryResult{}
+	for _, result := range results {
+		if result != nil {
+			appendResult(merged, result)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// queryMapExecutor answers each query with its own canned result or error.
+type queryMapExecutor struct {
+	mu       sync.Mutex
+	results  map[string]*util.QueryResult
+	errs     map[string]error
+	executed []string
+}
+
+func (e *queryMapExecutor) Execute(query string) (*util.QueryResult, error) {
+	e.mu.Lock()
+	e.executed = append(e.executed, query)
+	e.mu.Unlock()
+	return e.results[query], e.errs[query]
+}
+
+func newUnionTestDatasource() *Datasource {
+	executor := &queryMapExecutor{
+		results: map[string]*util.QueryResult{
+			"select id from a": {Columns: []string{"id"}, Records: [][]interface{}{{"a1"}, {"a2"}}},
+			"select id from c": {Columns: []string{"id"}, Records: [][]interface{}{{"c1"}}},
+		},
+		errs: map[string]error{"select id from b": errors.New("permission denied")},
+	}
+	return &Datasource{newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+		return executor, nil
+	}}
+}
+
+func TestQuerySubQueriesPartialResults(t *testing.T) {
+	resp := runQuery(t, newUnionTestDatasource(), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from a", "subQueries": ["select id from b", "select id from c"]}`)
+	require.NoError(t, resp.Error)
+
+	frame := resp.Frames[0]
+	require.Equal(t, 3, frame.Rows())
+	require.Equal(t, "c1", *frame.Fields[1].At(2).(*string))
+	require.Len(t, frame.Meta.Notices, 1)
+	require.Contains(t, frame.Meta.Notices[0].Text, "permission denied")
+}
+
+func TestQuerySubQueriesAbortOnFirstError(t *testing.T) {
+	resp := runQuery(t, newUnionTestDatasource(), FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from a", "subQueries": ["select id from b", "select id from c"], "abortOnFirstError": true}`)
+	require.Error(t, resp.Error)
+	require.Contains(t, resp.Error.Error(), "permission denied")
+	require.Empty(t, resp.Frames)
+}
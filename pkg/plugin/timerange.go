@@ -0,0 +1,28 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// limitTimeRange enforces the MinTimeRangeSecs and MaxTimeRangeDays bounds of
+// a query. A range narrower than minSecs is an error. A range wider than
+// maxDays is shortened to end at the same time, and a notice describing the
+// change is returned. Bounds that are not positive are ignored.
+func limitTimeRange(tr *backend.TimeRange, minSecs, maxDays int) (*data.Notice, error) {
+	duration := tr.Duration()
+	if minSecs > 0 && duration < time.Duration(minSecs)*time.Second {
+		return nil, fmt.Errorf("Time range is too narrow for this query; zoom out to at least %d seconds.", minSecs)
+	}
+	if maxDays > 0 && duration > time.Duration(maxDays)*24*time.Hour {
+		tr.From = tr.To.AddDate(0, 0, -maxDays)
+		return &data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Time range exceeds %d days; the query was limited to the last %d days of the range.", maxDays, maxDays),
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitTimeRange(t *testing.T) {
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	narrow := backend.TimeRange{From: to.Add(-30 * time.Second), To: to}
+	_, err := limitTimeRange(&narrow, 60, 0)
+	require.EqualError(t, err, "Time range is too narrow for this query; zoom out to at least 60 seconds.")
+
+	wide := backend.TimeRange{From: to.AddDate(0, 0, -30), To: to}
+	notice, err := limitTimeRange(&wide, 60, 7)
+	require.NoError(t, err)
+	require.NotNil(t, notice)
+	require.Equal(t, to.AddDate(0, 0, -7), wide.From)
+	require.Equal(t, to, wide.To)
+
+	within := backend.TimeRange{From: to.AddDate(0, 0, -7), To: to}
+	notice, err = limitTimeRange(&within, 60, 7)
+	require.NoError(t, err)
+	require.Nil(t, notice)
+	require.Equal(t, to.AddDate(0, 0, -7), within.From)
+}
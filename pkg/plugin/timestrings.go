@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"time"
+
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// defaultTimestampFormats are the layouts AutoParseTimestamps tries when the
+// query does not set TimestampFormats.
+var defaultTimestampFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTimestampColumns converts the string columns of result whose values
+// all parse as timestamps with the same layout of formats into time.Time
+// values. A column is left untouched as soon as one of its values is not a
+// string, does not parse or needs another layout than the previous ones.
+func parseTimestampColumns(result *util.QueryResult, formats []string) {
+	if len(formats) == 0 {
+		formats = defaultTimestampFormats
+	}
+
+	for col := range result.Columns {
+		times, ok := parseTimestampColumn(result.Records, col, formats)
+		if !ok {
+			continue
+		}
+		for i, record := range result.Records {
+			if t := times[i]; t != nil {
+				record[col] = *t
+			}
+		}
+	}
+}
+
+// parseTimestampColumn returns the timestamps of column col of records,
+// or false when the column is not made of timestamp strings of a single
+// layout.
+func parseTimestampColumn(records [][]interface{}, col int, formats []string) ([]*time.Time, bool) {
+	times := make([]*time.Time, len(records))
+	layout := ""
+	for i, record := range records {
+		if col >= len(record) || record[col] == nil {
+			continue
+		}
+		s, ok := record[col].(string)
+		if !ok {
+			return nil, false
+		}
+		if layout == "" {
+			for _, f := range formats {
+				if _, err := time.Parse(f, s); err == nil {
+					layout = f
+					break
+				}
+			}
+			if layout == "" {
+				return nil, false
+			}
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, false
+		}
+		times[i] = &t
+	}
+	return times, layout != ""
+}
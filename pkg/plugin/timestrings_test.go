@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimestampColumns(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"created", "day", "mixed", "name", "count"},
+		Records: [][]interface{}{
+			{"2024-03-15T10:30:00Z", "2024-03-15", "2024-03-15", "alice", int64(1)},
+			{nil, "2024-03-16", "2024-03-16T10:30:00+02:00", "2024-03-15", int64(2)},
+			{"2024-03-15T12:00:00+01:00", nil, nil, "bob", int64(3)},
+		},
+	}
+	parseTimestampColumns(result, nil)
+
+	require.Equal(t, time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC), result.Records[0][0])
+	require.Nil(t, result.Records[1][0])
+	require.Equal(t, time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC), result.Records[2][0].(time.Time).UTC())
+	require.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), result.Records[1][1])
+
+	// Mixed layouts, non-timestamp strings and non-strings are left as is.
+	require.Equal(t, "2024-03-16T10:30:00+02:00", result.Records[1][2])
+	require.Equal(t, "2024-03-15", result.Records[1][3])
+	require.Equal(t, int64(1), result.Records[0][4])
+}
+
+func TestParseTimestampColumnsCustomFormats(t *testing.T) {
+	result := &util.QueryResult{
+		Columns: []string{"at"},
+		Records: [][]interface{}{{"15/03/2024"}, {"16/03/2024"}},
+	}
+	parseTimestampColumns(result, []string{"02/01/2006"})
+	require.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), result.Records[1][0])
+
+	frame := newResponseFrame(result, false)
+	field, _ := frame.FieldByName("at")
+	require.Equal(t, data.FieldTypeNullableTime, field.Type())
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// tlsClientOptions returns the client options applying the custom TLS
+// settings of the datasource, or nil when none are configured.
+func tlsClientOptions(settings FirestoreSettings) ([]option.ClientOption, error) {
+	if settings.TLSCACertPEM == "" && !settings.TLSSkipVerify {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if settings.TLSCACertPEM != "" {
+		pem, err := base64.StdEncoding.DecodeString(settings.TLSCACertPEM)
+		if err != nil {
+			return nil, fmt.Errorf("TLSCACertPEM: invalid base64: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("TLSCACertPEM: no valid PEM certificates found")
+		}
+		config.RootCAs = pool
+	}
+	if settings.TLSSkipVerify {
+		log.DefaultLogger.Warn("TLS certificate verification is DISABLED for Firestore connections. " +
+			"Traffic can be intercepted; use TLSCACertPEM instead and never enable TLSSkipVerify in production.")
+		config.InsecureSkipVerify = true
+	}
+
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(config))),
+	}, nil
+}
@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFirestoreClientInvalidCACert(t *testing.T) {
+	// The CA certificate is not used to connect to the emulator.
+	t.Setenv(emulatorHostEnvVar, "")
+	for _, caCert := range []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("-----BEGIN CERTIFICATE-----\ngarbage\n-----END CERTIFICATE-----\n")),
+	} {
+		jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test", TLSCACertPEM: caCert})
+		require.NoError(t, err)
+
+		_, err = newFirestoreClient(context.Background(), backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		})
+		require.ErrorContains(t, err, "TLSCACertPEM")
+	}
+}
+
+func TestTLSClientOptions(t *testing.T) {
+	options, err := tlsClientOptions(FirestoreSettings{})
+	require.NoError(t, err)
+	require.Empty(t, options)
+
+	options, err = tlsClientOptions(FirestoreSettings{TLSSkipVerify: true})
+	require.NoError(t, err)
+	require.Len(t, options, 1)
+}
@@ -0,0 +1,484 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// FirestoreTransformer is a single post-processing step applied to the
+// response frame. Transformers run in the order they are declared in
+// FirestoreQuery.Transformers.
+type FirestoreTransformer struct {
+	Type    string
+	Options map[string]interface{}
+}
+
+// frameTransformers maps a transformer type to its implementation.
+var frameTransformers = map[string]func(frame *data.Frame, options map[string]interface{}) (*data.Frame, error){
+	"sort":     sortTransformer,
+	"limit":    limitTransformer,
+	"flatten":  flattenTransformer,
+	"trim":     trimTransformer,
+	"group_by": groupByTransformer,
+}
+
+// applyTransformers runs the frame through each transformer in sequence.
+// sortLocale is used by sort transformers that do not set their own "locale".
+func applyTransformers(frame *data.Frame, transformers []FirestoreTransformer, sortLocale string) (*data.Frame, error) {
+	for _, t := range transformers {
+		transform, ok := frameTransformers[t.Type]
+		if !ok {
+			return nil, fmt.Errorf("unsupported transformer %q", t.Type)
+		}
+		options := t.Options
+		if t.Type == "sort" && sortLocale != "" && optionString(options, "locale") == "" {
+			options = make(map[string]interface{}, len(t.Options)+1)
+			for k, v := range t.Options {
+				options[k] = v
+			}
+			options["locale"] = sortLocale
+		}
+		var err error
+		frame, err = transform(frame, options)
+		if err != nil {
+			return nil, fmt.Errorf("transformer %q: %v", t.Type, err)
+		}
+	}
+	return frame, nil
+}
+
+// sortTransformer orders rows by the "field" option, ascending unless "desc" is true.
+// Strings are compared byte-wise unless a "locale" option such as "fr" is set,
+// in which case they are collated according to that language.
+func sortTransformer(frame *data.Frame, options map[string]interface{}) (*data.Frame, error) {
+	name := optionString(options, "field")
+	field, _ := frame.FieldByName(name)
+	if field == nil {
+		return nil, fmt.Errorf("unknown field %q", name)
+	}
+	desc := optionBool(options, "desc")
+	compareStrings := strings.Compare
+	if locale := optionString(options, "locale"); locale != "" {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q: %v", locale, err)
+		}
+		compareStrings = collate.New(tag).CompareString
+	}
+
+	rows := make([]int, field.Len())
+	for i := range rows {
+		rows[i] = i
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		c := compareValuesWith(field.At(rows[i]), field.At(rows[j]), compareStrings)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	return selectRows(frame, rows), nil
+}
+
+// limitTransformer keeps the first "count" rows.
+func limitTransformer(frame *data.Frame, options map[string]interface{}) (*data.Frame, error) {
+	count := optionInt(options, "count")
+	if count < 0 {
+		return nil, fmt.Errorf("count must not be negative")
+	}
+	n := frame.Rows()
+	if count >= n {
+		return frame, nil
+	}
+	rows := make([]int, count)
+	for i := range rows {
+		rows[i] = i
+	}
+	return selectRows(frame, rows), nil
+}
+
+// flattenTransformer expands string fields holding JSON objects into one
+// field per key, named "<field>.<key>". The "fields" option restricts the
+// transformer to the listed fields.
+func flattenTransformer(frame *data.Frame, options map[string]interface{}) (*data.Frame, error) {
+	only := optionStrings(options, "fields")
+	out := data.NewFrame(frame.Name)
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	for _, field := range frame.Fields {
+		if field.Type() != data.FieldTypeNullableString || (len(only) > 0 && !containsString(only, field.Name)) {
+			out.Fields = append(out.Fields, field)
+			continue
+		}
+		objects, keys, ok := jsonObjectValues(field)
+		if !ok {
+			out.Fields = append(out.Fields, field)
+			continue
+		}
+		for _, key := range keys {
+			values := make([]*string, field.Len())
+			for i, object := range objects {
+				if v, ok := object.Get(key); ok && v != nil {
+					s := stringifyValue(v)
+					values[i] = &s
+				}
+			}
+			out.Fields = append(out.Fields, data.NewField(field.Name+"."+key, field.Labels, values))
+		}
+	}
+	return out, nil
+}
+
+// jsonObjectValues decodes every non-nil value of a string field as a JSON
+// object. Keys are returned in the order they first appear. It reports false
+// if any value is not an object.
+func jsonObjectValues(field *data.Field) ([]OrderedMap, []string, bool) {
+	objects := make([]OrderedMap, field.Len())
+	seen := map[string]bool{}
+	var keys []string
+	for i := 0; i < field.Len(); i++ {
+		v, ok := field.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, nil, false
+		}
+		if !strings.HasPrefix(strings.TrimSpace(s), "{") {
+			return nil, nil, false
+		}
+		if err := json.Unmarshal([]byte(s), &objects[i]); err != nil {
+			return nil, nil, false
+		}
+		for _, key := range objects[i].Keys() {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil, false
+	}
+	return objects, keys, true
+}
+
+// trimTransformer removes leading and trailing whitespace from string values.
+// The "fields" option restricts the transformer to the listed fields.
+func trimTransformer(frame *data.Frame, options map[string]interface{}) (*data.Frame, error) {
+	only := optionStrings(options, "fields")
+	for _, field := range frame.Fields {
+		if field.Type() != data.FieldTypeNullableString || (len(only) > 0 && !containsString(only, field.Name)) {
+			continue
+		}
+		for i := 0; i < field.Len(); i++ {
+			if v, ok := field.ConcreteAt(i); ok {
+				trimmed := strings.TrimSpace(v.(string))
+				field.Set(i, &trimmed)
+			}
+		}
+	}
+	return frame, nil
+}
+
+// groupByTransformer collapses rows sharing the same "field" value. With the
+// default "count" aggregation the result holds the group key and a count
+// column; "sum", "avg", "min" and "max" aggregate every numeric field.
+func groupByTransformer(frame *data.Frame, options map[string]interface{}) (*data.Frame, error) {
+	name := optionString(options, "field")
+	keyField, _ := frame.FieldByName(name)
+	if keyField == nil {
+		return nil, fmt.Errorf("unknown field %q", name)
+	}
+	aggregation := optionString(options, "aggregation")
+	if aggregation == "" {
+		aggregation = "count"
+	}
+
+	groups := groupRows(keyField.Len(), keyField.At)
+
+	out := data.NewFrame(frame.Name)
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	keyValues := make([]*string, len(groups))
+	for i, group := range groups {
+		key := stringifyValue(keyField.At(group[0]))
+		keyValues[i] = &key
+	}
+	out.Fields = append(out.Fields, data.NewField(keyField.Name, keyField.Labels, keyValues))
+
+	if aggregation == "count" {
+		counts := make([]*int64, len(groups))
+		for i, group := range groups {
+			n := int64(len(group))
+			counts[i] = &n
+		}
+		out.Fields = append(out.Fields, data.NewField("count", nil, counts))
+		return out, nil
+	}
+
+	for _, field := range frame.Fields {
+		if field == keyField || !field.Type().Numeric() {
+			continue
+		}
+		values := make([]*float64, len(groups))
+		for i, group := range groups {
+			v, err := aggregateFloats(group, field.NullableFloatAt, aggregation)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		out.Fields = append(out.Fields, data.NewField(field.Name, field.Labels, values))
+	}
+	return out, nil
+}
+
+// groupRows groups the rows 0 to n-1 by the value key returns for them, in
+// order of first appearance. Values are told apart by stringifyValue.
+func groupRows(n int, key func(row int) interface{}) [][]int {
+	var groups [][]int
+	index := map[string]int{}
+	for row := 0; row < n; row++ {
+		k := stringifyValue(key(row))
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], row)
+	}
+	return groups
+}
+
+// aggregateFloats applies a numeric aggregation over the values of the given
+// rows, ignoring nil values. It returns nil when every value is nil.
+func aggregateFloats(rows []int, value func(row int) (*float64, error), aggregation string) (*float64, error) {
+	switch aggregation {
+	case "sum", "avg", "min", "max":
+	default:
+		return nil, fmt.Errorf("unsupported aggregation %q", aggregation)
+	}
+	var result float64
+	n := 0
+	for _, row := range rows {
+		v, err := value(row)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			result = *v
+		case aggregation == "sum" || aggregation == "avg":
+			result += *v
+		case aggregation == "min" && *v < result:
+			result = *v
+		case aggregation == "max" && *v > result:
+			result = *v
+		}
+		n++
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	if aggregation == "avg" {
+		result /= float64(n)
+	}
+	return &result, nil
+}
+
+// selectRows returns a copy of frame holding only the given rows, in order.
+func selectRows(frame *data.Frame, rows []int) *data.Frame {
+	out := data.NewFrame(frame.Name)
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	for _, field := range frame.Fields {
+		copied := data.NewFieldFromFieldType(field.Type(), len(rows))
+		copied.Name = field.Name
+		copied.Labels = field.Labels
+		copied.Config = field.Config
+		for i, row := range rows {
+			copied.Set(i, field.CopyAt(row))
+		}
+		out.Fields = append(out.Fields, copied)
+	}
+	return out
+}
+
+// compareValues orders two frame values of the same field. Nil values sort first.
+func compareValues(a, b interface{}) int {
+	return compareValuesWith(a, b, strings.Compare)
+}
+
+// compareValuesWith is compareValues using compareStrings to order strings.
+func compareValuesWith(a, b interface{}, compareStrings func(a, b string) int) int {
+	a, b = derefValue(a), derefValue(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch av := a.(type) {
+	case string:
+		return compareStrings(av, b.(string))
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		} else if !av {
+			return -1
+		}
+		return 1
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	}
+	af, aok := toFloat64(a)
+	bf, bok := toFloat64(b)
+	if aok && bok {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// derefValue unwraps the pointer types stored in nullable frame fields.
+func derefValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case *string:
+		if val != nil {
+			return *val
+		}
+	case *bool:
+		if val != nil {
+			return *val
+		}
+	case *int64:
+		if val != nil {
+			return *val
+		}
+	case *float64:
+		if val != nil {
+			return *val
+		}
+	case *time.Time:
+		if val != nil {
+			return *val
+		}
+	default:
+		return v
+	}
+	return nil
+}
+
+// toFloat64 converts any Go numeric value to float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case int:
+		return float64(val), true
+	case int8:
+		return float64(val), true
+	case int16:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case uint:
+		return float64(val), true
+	case uint8:
+		return float64(val), true
+	case uint16:
+		return float64(val), true
+	case uint32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case float32:
+		return float64(val), true
+	case float64:
+		return val, true
+	}
+	return 0, false
+}
+
+// stringifyValue renders a frame or Firestore value as a string.
+func stringifyValue(v interface{}) string {
+	switch val := derefValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case map[string]interface{}, []interface{}, OrderedMap:
+		b, err := json.Marshal(val)
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", derefValue(v))
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func optionString(options map[string]interface{}, key string) string {
+	if s, ok := options[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+func optionBool(options map[string]interface{}, key string) bool {
+	b, _ := options[key].(bool)
+	return b
+}
+
+func optionInt(options map[string]interface{}, key string) int {
+	if f, ok := toFloat64(options[key]); ok {
+		return int(f)
+	}
+	return 0
+}
+
+func optionStrings(options map[string]interface{}, key string) []string {
+	switch values := options[key].(type) {
+	case []string:
+		return values
+	case []interface{}:
+		var out []string
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
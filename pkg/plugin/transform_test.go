@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func newTransformTestFrame() *data.Frame {
+	ids := make([]*string, 10)
+	scores := make([]*float64, 10)
+	addresses := make([]*string, 10)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("doc%d", i)
+		score := float64((i * 7) % 10)
+		address := fmt.Sprintf(`{"city": "city%d", "zip": "%05d"}`, i, i)
+		ids[i], scores[i], addresses[i] = &id, &score, &address
+	}
+	return data.NewFrame("response",
+		data.NewField("__document_id", nil, ids),
+		data.NewField("score", nil, scores),
+		data.NewField("address", nil, addresses),
+	)
+}
+
+func TestApplyTransformersPipeline(t *testing.T) {
+	frame, err := applyTransformers(newTransformTestFrame(), []FirestoreTransformer{
+		{Type: "sort", Options: map[string]interface{}{"field": "score", "desc": true}},
+		{Type: "limit", Options: map[string]interface{}{"count": float64(3)}},
+		{Type: "flatten"},
+	}, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, frame.Rows())
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, "address.city", frame.Fields[2].Name)
+	require.Equal(t, "address.zip", frame.Fields[3].Name)
+
+	// score = (i * 7) % 10, so the top three scores are 9, 8 and 7
+	for i, expected := range []struct {
+		id    string
+		score float64
+		city  string
+	}{{"doc7", 9, "city7"}, {"doc4", 8, "city4"}, {"doc1", 7, "city1"}} {
+		require.Equal(t, expected.id, *frame.Fields[0].At(i).(*string))
+		require.Equal(t, expected.score, *frame.Fields[1].At(i).(*float64))
+		require.Equal(t, expected.city, *frame.Fields[2].At(i).(*string))
+	}
+}
+
+func TestApplyTransformersTrimAndGroupBy(t *testing.T) {
+	regions := []string{" eu ", "us", "eu", "us ", "eu"}
+	regionValues := make([]*string, len(regions))
+	for i := range regions {
+		regionValues[i] = &regions[i]
+	}
+	frame := data.NewFrame("response",
+		data.NewField("region", nil, regionValues),
+		data.NewField("sales", nil, []*float64{floatPtr(1), floatPtr(2), nil, floatPtr(4), floatPtr(5)}),
+	)
+
+	frame, err := applyTransformers(frame, []FirestoreTransformer{
+		{Type: "trim"},
+		{Type: "group_by", Options: map[string]interface{}{"field": "region", "aggregation": "sum"}},
+	}, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, frame.Rows())
+	require.Equal(t, "eu", *frame.Fields[0].At(0).(*string))
+	require.Equal(t, 6.0, *frame.Fields[1].At(0).(*float64))
+	require.Equal(t, "us", *frame.Fields[0].At(1).(*string))
+	require.Equal(t, 6.0, *frame.Fields[1].At(1).(*float64))
+}
+
+func TestApplyTransformersSortLocale(t *testing.T) {
+	newFrame := func() *data.Frame {
+		words := []string{"fab", "élan", "zèbre"}
+		values := make([]*string, len(words))
+		for i := range words {
+			values[i] = &words[i]
+		}
+		return data.NewFrame("response", data.NewField("word", nil, values))
+	}
+	sortByWord := []FirestoreTransformer{{Type: "sort", Options: map[string]interface{}{"field": "word"}}}
+
+	frame, err := applyTransformers(newFrame(), sortByWord, "fr")
+	require.NoError(t, err)
+	require.Equal(t, "élan", *frame.Fields[0].At(0).(*string))
+	require.Equal(t, "fab", *frame.Fields[0].At(1).(*string))
+
+	// Without a locale strings are sorted in binary order
+	frame, err = applyTransformers(newFrame(), sortByWord, "")
+	require.NoError(t, err)
+	require.Equal(t, "fab", *frame.Fields[0].At(0).(*string))
+	require.Equal(t, "élan", *frame.Fields[0].At(2).(*string))
+
+	_, err = applyTransformers(newFrame(), sortByWord, "not a locale!")
+	require.Error(t, err)
+}
+
+func TestApplyTransformersUnknownType(t *testing.T) {
+	_, err := applyTransformers(newTransformTestFrame(), []FirestoreTransformer{{Type: "pivot"}}, "")
+	require.Error(t, err)
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
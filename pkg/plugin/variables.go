@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// variableQueryType is the query type of dashboard variable queries.
+	variableQueryType = "variables"
+	// variableValueField names the field holding the values of a variable.
+	variableValueField = "__value"
+	maxVariableValues  = 1000
+)
+
+// Variable query modes.
+const (
+	variableModeCollections = "collections"
+	variableModeFieldValues = "fieldValues"
+)
+
+// VariableQuery is the model of the queries populating dashboard variables.
+// The "collections" mode lists the root collections, the "fieldValues" mode
+// the distinct values of Field in Collection, optionally restricted by the
+// Filter WHERE condition.
+type VariableQuery struct {
+	Mode       string
+	Collection string
+	Field      string
+	Filter     string
+}
+
+// variableQuery returns a single-field frame holding the values of a
+// dashboard variable, at most maxVariableValues of them.
+func (d *Datasource) variableQuery(ctx context.Context, pCtx backend.PluginContext, executor queryExecutor, vq VariableQuery, settings FirestoreSettings) (*data.Frame, error) {
+	var values []string
+	switch vq.Mode {
+	case variableModeCollections:
+		collections, err := d.collectionPaths(ctx, pCtx, "")
+		if err != nil {
+			return nil, err
+		}
+		values = collections
+	case variableModeFieldValues:
+		if vq.Collection == "" || vq.Field == "" {
+			return nil, errors.New("Collection and Field are required for fieldValues variable queries")
+		}
+		query := fmt.Sprintf("SELECT %s FROM %s", vq.Field, vq.Collection)
+		if vq.Filter != "" {
+			query += " WHERE " + vq.Filter
+		}
+		result, err := executeQuery(ctx, executor, query, settings)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		for _, record := range result.Records {
+			if len(record) == 0 || record[0] == nil {
+				continue
+			}
+			value, err := formatValue(record[0])
+			if err != nil || seen[value] {
+				continue
+			}
+			seen[value] = true
+			values = append(values, value)
+		}
+	default:
+		return nil, fmt.Errorf("unknown variable query mode %q", vq.Mode)
+	}
+
+	truncated := len(values) > maxVariableValues
+	if truncated {
+		values = values[:maxVariableValues]
+	}
+	frame := data.NewFrame("variables", data.NewField(variableValueField, nil, values))
+	if truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Only the first %d values are returned", maxVariableValues),
+		})
+	}
+	return frame, nil
+}
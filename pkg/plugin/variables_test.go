@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// runVariableQuery executes a dashboard variable query.
+func runVariableQuery(t *testing.T, ds *Datasource, queryJSON string) backend.DataResponse {
+	jsonSettings, err := json.Marshal(FirestoreSettings{ProjectId: "test"})
+	require.NoError(t, err)
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: jsonSettings},
+		},
+		Queries: []backend.DataQuery{{RefID: "A", QueryType: variableQueryType, JSON: []byte(queryJSON)}},
+	})
+	require.NoError(t, err)
+	return resp.Responses["A"]
+}
+
+func TestVariableQueryCollections(t *testing.T) {
+	ds := newMockDatasource(&mockExecutor{})
+	ds.listCollections = func(context.Context, backend.PluginContext, string) ([]string, error) {
+		return []string{"stores", "users"}, nil
+	}
+
+	resp := runVariableQuery(t, ds, `{"mode": "collections"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, data.NewField(variableValueField, nil, []string{"stores", "users"}), resp.Frames[0].Fields[0])
+}
+
+func TestVariableQueryFieldValues(t *testing.T) {
+	mock := &mockExecutor{results: []*util.QueryResult{{
+		Columns: []string{"region"},
+		Records: [][]interface{}{{"eu"}, {"us"}, {nil}, {"eu"}},
+	}}}
+	ds := newMockDatasource(mock)
+
+	resp := runVariableQuery(t, ds, `{"mode": "fieldValues", "collection": "stores", "field": "region", "filter": "open = true"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, []string{"SELECT region FROM stores WHERE open = true"}, mock.queries)
+	frame := resp.Frames[0]
+	require.Len(t, frame.Fields, 1)
+	require.Equal(t, data.NewField(variableValueField, nil, []string{"eu", "us"}), frame.Fields[0])
+	require.Nil(t, frame.Meta)
+
+	resp = runVariableQuery(t, ds, `{"mode": "fieldValues", "collection": "stores"}`)
+	require.Error(t, resp.Error)
+	resp = runVariableQuery(t, ds, `{"mode": "documents"}`)
+	require.Error(t, resp.Error)
+}
+
+func TestVariableQueryTruncated(t *testing.T) {
+	result := &util.QueryResult{Columns: []string{"id"}}
+	for i := 0; i < maxVariableValues+10; i++ {
+		result.Records = append(result.Records, []interface{}{fmt.Sprint(i)})
+	}
+	ds := newMockDatasource(&mockExecutor{results: []*util.QueryResult{result}})
+
+	resp := runVariableQuery(t, ds, `{"mode": "fieldValues", "collection": "users", "field": "id"}`)
+	require.NoError(t, resp.Error)
+	require.Equal(t, maxVariableValues, resp.Frames[0].Rows())
+	require.Len(t, resp.Frames[0].Meta.Notices, 1)
+}
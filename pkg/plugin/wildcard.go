@@ -0,0 +1,102 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql/pkg/util"
+)
+
+// sourceCollectionField holds the collection each row of a WildcardCollection
+// query was read from.
+const sourceCollectionField = "__source_collection"
+
+// wildcardQuery runs the query against every root collection whose name
+// matches the WildcardCollection glob pattern, in name order, and appends
+// their results along with a sourceCollectionField column.
+func (d *Datasource) wildcardQuery(ctx context.Context, pCtx backend.PluginContext, executor queryExecutor, qm FirestoreQuery, settings FirestoreSettings) (*util.QueryResult, error) {
+	if _, err := path.Match(qm.WildcardCollection, ""); err != nil {
+		return nil, fmt.Errorf("WildcardCollection: %v", err)
+	}
+	collections, err := d.collectionPaths(ctx, pCtx, "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(collections)
+
+	var queries []string
+	sources := map[string]string{}
+	for _, collection := range collections {
+		if matched, _ := path.Match(qm.WildcardCollection, collection); !matched {
+			continue
+		}
+		query, err := replaceCollectionName(qm.Query, collection)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, query)
+		sources[query] = collection
+	}
+	if len(queries) == 0 {
+		return &util.QueryResult{}, nil
+	}
+
+	result, _, err := runUnion(ctx, queries, func(ctx context.Context, query string) (*util.QueryResult, error) {
+		result, err := executeQuery(ctx, executor, query, settings)
+		if err != nil {
+			return nil, err
+		}
+		tagged := &util.QueryResult{
+			Columns: append(append([]string(nil), result.Columns...), sourceCollectionField),
+			Records: make([][]interface{}, len(result.Records)),
+		}
+		for i, record := range result.Records {
+			// Pad short records so the source lines up with its column
+			padded := make([]interface{}, len(result.Columns), len(result.Columns)+1)
+			copy(padded, record)
+			tagged.Records[i] = append(padded, sources[query])
+		}
+		return tagged, nil
+	}, true)
+	return result, err
+}
+
+// replaceCollectionName replaces the collection of the FROM clause of query.
+func replaceCollectionName(query, collection string) (string, error) {
+	match := collectionNameRegex.FindStringSubmatchIndex(query)
+	if match == nil {
+		return "", fmt.Errorf("no collection found in query %q", query)
+	}
+	return query[:match[2]] + collection + query[match[3]:], nil
+}
+
+// extractSourceCollections removes the sourceCollectionField column from
+// result and returns its values.
+func extractSourceCollections(result *util.QueryResult) []*string {
+	column := -1
+	for i, name := range result.Columns {
+		if name == sourceCollectionField {
+			column = i
+			break
+		}
+	}
+	sources := make([]*string, len(result.Records))
+	if column == -1 {
+		return sources
+	}
+
+	result.Columns = append(result.Columns[:column:column], result.Columns[column+1:]...)
+	for i, record := range result.Records {
+		if column >= len(record) {
+			continue
+		}
+		if source, ok := record[column].(string); ok {
+			sources[i] = &source
+		}
+		result.Records[i] = append(record[:column:column], record[column+1:]...)
+	}
+	return sources
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/pgollangi/fireql"
+	"github.com/pgollangi/fireql/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryWildcardCollection(t *testing.T) {
+	executor := &queryMapExecutor{results: map[string]*util.QueryResult{
+		"select id from events_2023": {Columns: []string{"id"}, Records: [][]interface{}{{"a"}, {"b"}}},
+		"select id from events_2024": {Columns: []string{"id"}, Records: [][]interface{}{{"c"}}},
+		"select id from users":       {Columns: []string{"id"}, Records: [][]interface{}{{"u"}}},
+	}}
+	ds := &Datasource{
+		newExecutor: func(string, ...fireql.Option) (queryExecutor, error) {
+			return executor, nil
+		},
+		listCollections: func(context.Context, backend.PluginContext, string) ([]string, error) {
+			return []string{"users", "events_2024", "events_2023"}, nil
+		},
+	}
+
+	resp := runQuery(t, ds, FirestoreSettings{ProjectId: "test"},
+		`{"query": "select id from events", "wildcardCollection": "events_*"}`)
+	require.NoError(t, resp.Error)
+	require.ElementsMatch(t, []string{"select id from events_2023", "select id from events_2024"}, executor.executed)
+
+	frame := resp.Frames[0]
+	require.Equal(t, 3, frame.Rows())
+	ids, _ := frame.FieldByName("id")
+	sources, _ := frame.FieldByName(sourceCollectionField)
+	require.NotNil(t, sources)
+	for i, want := range [][2]string{{"a", "events_2023"}, {"b", "events_2023"}, {"c", "events_2024"}} {
+		require.Equal(t, want[0], *ids.At(i).(*string))
+		require.Equal(t, want[1], *sources.At(i).(*string))
+	}
+}
+
+func TestReplaceCollectionName(t *testing.T) {
+	query, err := replaceCollectionName("SELECT * FROM `events` WHERE a = 1", "events_2024")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM `events_2024` WHERE a = 1", query)
+
+	_, err = replaceCollectionName("SELECT 1", "events")
+	require.Error(t, err)
+}
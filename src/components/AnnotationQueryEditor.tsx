@@ -0,0 +1,37 @@
+import React, { ChangeEvent, PureComponent } from 'react';
+import { InlineField, Input } from '@grafana/ui';
+import { QueryEditorProps } from '@grafana/data';
+import { DataSource } from '../datasource';
+import { MyDataSourceOptions, FirestoreQuery } from '../types';
+
+type Props = QueryEditorProps<DataSource, FirestoreQuery, MyDataSourceOptions>;
+
+type AnnotationField = 'collection' | 'timeField' | 'textField' | 'tagsField';
+
+export class AnnotationQueryEditor extends PureComponent<Props> {
+  onFieldChange = (key: AnnotationField) => (event: ChangeEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    onChange({ ...query, [key]: event.target.value.trim() });
+  };
+
+  render() {
+    const { collection, timeField, textField, tagsField } = this.props.query;
+
+    return (
+      <div>
+        <InlineField label="Collection" labelWidth={14} tooltip="Each document of the collection in the time range is an annotation">
+          <Input value={collection || ''} placeholder="deployments" width={30} onChange={this.onFieldChange('collection')} />
+        </InlineField>
+        <InlineField label="Time field" labelWidth={14} tooltip="A timestamp, or an RFC 3339 string in UTC">
+          <Input value={timeField || ''} placeholder="deployedAt" width={30} onChange={this.onFieldChange('timeField')} />
+        </InlineField>
+        <InlineField label="Text field" labelWidth={14}>
+          <Input value={textField || ''} placeholder="message" width={30} onChange={this.onFieldChange('textField')} />
+        </InlineField>
+        <InlineField label="Tags field" labelWidth={14} tooltip="An array of tags, or a single tag">
+          <Input value={tagsField || ''} placeholder="tags" width={30} onChange={this.onFieldChange('tagsField')} />
+        </InlineField>
+      </div>
+    );
+  }
+}
@@ -26,6 +26,25 @@ export class ConfigEditor extends PureComponent<Props, State> {
     onOptionsChange({ ...options, jsonData });
   };
 
+  onEmulatorHostChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const { onOptionsChange, options } = this.props;
+    const jsonData = {
+      ...options.jsonData,
+      emulatorHost: event.target.value.trim(),
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  onNumberOptionChange = (key: 'defaultLimit' | 'maxDocuments') => (event: ChangeEvent<HTMLInputElement>) => {
+    const { onOptionsChange, options } = this.props;
+    const value = parseInt(event.target.value, 10);
+    const jsonData = {
+      ...options.jsonData,
+      [key]: isNaN(value) ? undefined : value,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
   // Secure field (only sent to the backend)
   onServiceAccountChange = (event: ChangeEvent<HTMLTextAreaElement>) => {
     const { onOptionsChange, options } = this.props;
@@ -78,6 +97,35 @@ export class ConfigEditor extends PureComponent<Props, State> {
               placeholder="(default)"
               width={40}></Input>
           </InlineField>
+          <InlineField label="Emulator Host" labelWidth={20}
+            tooltip="Address of a local Firestore emulator, e.g. localhost:8080. Credentials are not used when set.">
+             {/* @ts-ignore */}
+            <Input
+              onChange={this.onEmulatorHostChange}
+              value={jsonData.emulatorHost || ''}
+              placeholder="localhost:8080"
+              width={40}></Input>
+          </InlineField>
+          <InlineField label="Default Limit" labelWidth={20}
+            tooltip="LIMIT applied to queries without one. Firestore bills every document read, so this keeps queries from scanning whole collections by accident.">
+             {/* @ts-ignore */}
+            <Input
+              type="number"
+              onChange={this.onNumberOptionChange('defaultLimit')}
+              value={jsonData.defaultLimit ?? ''}
+              placeholder="1000"
+              width={40}></Input>
+          </InlineField>
+          <InlineField label="Max Documents" labelWidth={20}
+            tooltip="Maximum number of documents returned by a query; larger results are truncated with a warning. Documents beyond this cap may still be read, and billed, unless the query has a LIMIT.">
+             {/* @ts-ignore */}
+            <Input
+              type="number"
+              onChange={this.onNumberOptionChange('maxDocuments')}
+              value={jsonData.maxDocuments ?? ''}
+              placeholder="10000"
+              width={40}></Input>
+          </InlineField>
           <InlineField required label="Service Account" labelWidth={20}
             tooltip="Service Account having previliges to read all firestore resources. Least role expected is 'roles/datastore.viewer'">
              {/* @ts-ignore */}
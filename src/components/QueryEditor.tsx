@@ -1,7 +1,7 @@
 import React, { ChangeEvent, PureComponent } from 'react';
 import {
-  QueryField, Button
-  // Form, InlineField, InlineFieldRow, Input
+  QueryField, Button, InlineField, Input
+  // Form, InlineFieldRow
 } from '@grafana/ui';
 // import { FieldValues } from "react-hook-form"
 import { QueryEditorProps } from '@grafana/data';
@@ -24,6 +24,11 @@ export class QueryEditor extends PureComponent<Props> {
     // this.runQuery(onRunQuery)
   };
 
+  onFrameNameChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    onChange({ ...query, frameName: event.target.value });
+  };
+
   onRunQuery = () => {
     const { onRunQuery } = this.props;
     onRunQuery();
@@ -44,7 +49,7 @@ export class QueryEditor extends PureComponent<Props> {
   }
 
   render() {
-    const {  query } = this.props.query;
+    const {  query, frameName } = this.props.query;
 
     // const defaultValues: FieldValues = {
     //       where: [{ field: 'Janis', op: 'Joplin', value: "Va" }],
@@ -56,6 +61,9 @@ export class QueryEditor extends PureComponent<Props> {
          <QueryField query={query} placeholder="FireQL query" portalOrigin="" onChange={this.onQueryChange}></QueryField>
          <Button style={{marginLeft: "10px"}} onClick={this.onRunQuery}>Run query</Button>
         </div>
+        <InlineField label="Frame name" labelWidth={14} tooltip="Defaults to the collection of the FROM clause">
+          <Input value={frameName || ''} placeholder="collection name" width={30} onChange={this.onFrameNameChange} onBlur={this.onRunQuery} />
+        </InlineField>
       </div>
     );
   }
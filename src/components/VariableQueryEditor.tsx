@@ -0,0 +1,49 @@
+import React, { ChangeEvent, PureComponent } from 'react';
+import { InlineField, Input, RadioButtonGroup } from '@grafana/ui';
+import { QueryEditorProps } from '@grafana/data';
+import { DataSource } from '../datasource';
+import { MyDataSourceOptions, FirestoreQuery, FirestoreVariableQuery, VariableQueryMode } from '../types';
+
+type Props = QueryEditorProps<DataSource, FirestoreQuery, MyDataSourceOptions, FirestoreVariableQuery>;
+
+const modes = [
+  { label: 'Collections', value: 'collections' as VariableQueryMode },
+  { label: 'Field values', value: 'fieldValues' as VariableQueryMode },
+];
+
+export class VariableQueryEditor extends PureComponent<Props> {
+  onModeChange = (mode: VariableQueryMode) => {
+    const { onChange, query } = this.props;
+    onChange({ ...query, mode });
+  };
+
+  onFieldChange = (key: 'collection' | 'field' | 'filter') => (event: ChangeEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    onChange({ ...query, [key]: event.target.value });
+  };
+
+  render() {
+    const { mode = 'collections', collection, field, filter } = this.props.query;
+
+    return (
+      <div>
+        <InlineField label="Values" labelWidth={14}>
+          <RadioButtonGroup options={modes} value={mode} onChange={this.onModeChange} />
+        </InlineField>
+        {mode === 'fieldValues' && (
+          <>
+            <InlineField label="Collection" labelWidth={14}>
+              <Input value={collection || ''} placeholder="stores" width={30} onChange={this.onFieldChange('collection')} />
+            </InlineField>
+            <InlineField label="Field" labelWidth={14} tooltip="The distinct values of the field, at most 1000">
+              <Input value={field || ''} placeholder="region" width={30} onChange={this.onFieldChange('field')} />
+            </InlineField>
+            <InlineField label="Filter" labelWidth={14} tooltip="An optional FireQL WHERE condition">
+              <Input value={filter || ''} placeholder="open = true" width={30} onChange={this.onFieldChange('filter')} />
+            </InlineField>
+          </>
+        )}
+      </div>
+    );
+  }
+}
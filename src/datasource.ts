@@ -1,11 +1,18 @@
 import { DataSourceInstanceSettings, CoreApp } from '@grafana/data';
 import { DataSourceWithBackend } from '@grafana/runtime';
 
-import { FirestoreQuery, MyDataSourceOptions, DEFAULT_QUERY } from './types';
+import { AnnotationQueryEditor } from './components/AnnotationQueryEditor';
+import { FirestoreVariableSupport } from './variables';
+import { FirestoreQuery, MyDataSourceOptions, DEFAULT_QUERY, ANNOTATION_QUERY_TYPE } from './types';
 
 export class DataSource extends DataSourceWithBackend<FirestoreQuery, MyDataSourceOptions> {
   constructor(instanceSettings: DataSourceInstanceSettings<MyDataSourceOptions>) {
     super(instanceSettings);
+    this.annotations = {
+      QueryEditor: AnnotationQueryEditor,
+      prepareQuery: (anno) => anno.target && { ...anno.target, queryType: ANNOTATION_QUERY_TYPE },
+    };
+    this.variables = new FirestoreVariableSupport(this);
   }
 
   getDefaultQuery(_: CoreApp): Partial<FirestoreQuery> {
@@ -2,8 +2,30 @@ import { DataQuery, DataSourceJsonData } from '@grafana/data';
 
 export interface FirestoreQuery extends DataQuery {
   query: string
+  frameName?: string
+  // Annotation queries, sent with the "annotation" query type
+  collection?: string
+  timeField?: string
+  textField?: string
+  tagsField?: string
 }
 
+export const ANNOTATION_QUERY_TYPE = 'annotation';
+
+export type VariableQueryMode = 'collections' | 'fieldValues';
+
+/**
+ * Query of the dashboard variables, sent with the "variables" query type
+ */
+export interface FirestoreVariableQuery extends DataQuery {
+  mode?: VariableQueryMode
+  collection?: string
+  field?: string
+  filter?: string
+}
+
+export const VARIABLE_QUERY_TYPE = 'variables';
+
 export const DEFAULT_QUERY: Partial<FirestoreQuery> = {
 };
 
@@ -14,6 +36,9 @@ export interface MyDataSourceOptions extends DataSourceJsonData {
   projectId: string;
   serviceAccount: string;
   databaseName: string; // New field for custom database name
+  defaultLimit?: number;
+  maxDocuments?: number;
+  emulatorHost?: string;
 }
 
 /**
@@ -0,0 +1,33 @@
+import { CustomVariableSupport, DataQueryRequest, DataQueryResponse } from '@grafana/data';
+import { Observable } from 'rxjs';
+
+import { VariableQueryEditor } from './components/VariableQueryEditor';
+import { DataSource } from './datasource';
+import { FirestoreQuery, FirestoreVariableQuery, MyDataSourceOptions, VARIABLE_QUERY_TYPE } from './types';
+
+/**
+ * Populates dashboard variables with the root collections, or the distinct
+ * values of a field, through the "variables" query type of the backend.
+ */
+export class FirestoreVariableSupport extends CustomVariableSupport<
+  DataSource,
+  FirestoreVariableQuery,
+  FirestoreQuery,
+  MyDataSourceOptions
+> {
+  editor = VariableQueryEditor;
+
+  constructor(private readonly datasource: DataSource) {
+    super();
+  }
+
+  query(request: DataQueryRequest<FirestoreVariableQuery>): Observable<DataQueryResponse> {
+    const targets = request.targets.map((target) => ({
+      ...target,
+      mode: target.mode ?? 'collections',
+      queryType: VARIABLE_QUERY_TYPE,
+      query: '',
+    }));
+    return this.datasource.query({ ...request, targets });
+  }
+}